@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+var codeActionKinds = []types.CodeActionKind{types.CodeActionQuickFix, types.CodeActionSourceFixAll}
+
+// CodeActions returns fixes offered by tools with a FixCommand.
+// Without a kind filter, fixes are only offered when the tool reported diagnostics in the range,
+// so that fix commands do not run on every cursor move.
+func (h *LangHandler) CodeActions(ctx context.Context, uri types.DocumentURI, rng types.Range, only []types.CodeActionKind) ([]types.CodeAction, error) {
+	f, ok := h.getFile(uri)
+	if !ok {
+		return nil, fmt.Errorf("document not found: %v", uri)
+	}
+
+	allConfigs := h.configsFor(f.NormalizedFilename)
+	actions := make([]types.CodeAction, 0)
+	for _, config := range getFixConfigsForDocument(f.NormalizedFilename, f.LanguageID, allConfigs) {
+		name := toolName(config, config.FixCommand)
+		fixAllKind := types.CodeActionSourceFixAll + types.CodeActionKind("."+name)
+
+		diagnostics := make([]types.Diagnostic, 0)
+		for _, d := range h.diagnostics.forTool(uri, lintToolKey(config)) {
+			if rangesOverlap(d.Range, rng) {
+				diagnostics = append(diagnostics, d)
+			}
+		}
+
+		var wantQuickFix, wantFixAll bool
+		if len(only) == 0 {
+			wantQuickFix = len(diagnostics) > 0
+			wantFixAll = len(diagnostics) > 0
+		} else {
+			wantQuickFix = len(diagnostics) > 0 && isKindRequested(only, types.CodeActionQuickFix)
+			wantFixAll = isKindRequested(only, fixAllKind)
+		}
+		if !wantQuickFix && !wantFixAll {
+			continue
+		}
+
+		rootPath := h.findRootPath(f.NormalizedFilename, config)
+		fixedText, err := fixDocument(ctx, rootPath, f, config, h.positionEncoding)
+		if err != nil {
+			logs.Log.Logln(logs.Error, err.Error())
+			continue
+		}
+		if fixedText == f.Text {
+			continue
+		}
+		edits, err := ComputeEdits(uri, f.Text, fixedText)
+		if err != nil {
+			return nil, err
+		}
+		edit := &types.WorkspaceEdit{Changes: map[types.DocumentURI][]types.TextEdit{uri: edits}}
+
+		if wantQuickFix {
+			actions = append(actions, types.CodeAction{
+				Title:       fmt.Sprintf("Fix all %s problems", name),
+				Kind:        types.CodeActionQuickFix,
+				Diagnostics: diagnostics,
+				IsPreferred: true,
+				Edit:        edit,
+			})
+		}
+		if wantFixAll {
+			actions = append(actions, types.CodeAction{
+				Title: fmt.Sprintf("Fix all auto-fixable %s problems", name),
+				Kind:  fixAllKind,
+				Edit:  edit,
+			})
+		}
+	}
+
+	return actions, nil
+}
+
+func fixDocument(ctx context.Context, rootPath string, f fileRef, config types.Language, encoding types.PositionEncodingKind) (string, error) {
+	cmdStr, err := buildFormatCommandString(rootPath, f.NormalizedFilename, f.Text, nil, nil, encoding, config.FixCommand)
+	if err != nil {
+		return "", fmt.Errorf("command build error: %s", err)
+	}
+
+	cmd := buildExecCmd(ctx, cmdStr, rootPath, f.Text, config, true)
+	out, err := runFormattingCommand(cmd)
+
+	logs.Log.Logln(logs.Info, cmdStr)
+	logs.Log.Logln(logs.Debug, out)
+
+	if err != nil {
+		return "", fmt.Errorf("fix error: %s", err)
+	}
+
+	return strings.ReplaceAll(out, carriageReturn, ""), nil
+}
+
+func getFixConfigsForDocument(fname, langId string, allConfigs map[string][]types.Language) []types.Language {
+	var configs []types.Language
+	for _, cfg := range getAllConfigsForLang(allConfigs, langId) {
+		if cfg.FixCommand == "" {
+			continue
+		}
+		if dir := matchRootPath(fname, cfg.RootMarkers); dir == "" && boolOrDefault(cfg.RequireMarker, false) {
+			continue
+		}
+		configs = append(configs, cfg)
+	}
+	return configs
+}
+
+// toolName is a short name of the tool used in titles, code action kinds and progress,
+// the lint source if set, otherwise the executable of the command.
+func toolName(config types.Language, command string) string {
+	if config.LintSource != "" {
+		return config.LintSource
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return filepath.Base(fields[0])
+}
+
+// isKindRequested reports whether kind matches the filter, kinds are hierarchical so "source" matches "source.fixAll".
+func isKindRequested(only []types.CodeActionKind, kind types.CodeActionKind) bool {
+	for _, o := range only {
+		if kind == o || strings.HasPrefix(string(kind), string(o)+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func rangesOverlap(a, b types.Range) bool {
+	return !positionBefore(a.End, b.Start) && !positionBefore(b.End, a.Start)
+}
+
+func positionBefore(a, b types.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeActions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sed")
+	}
+	base, _ := os.Getwd()
+	file := filepath.Join(base, "foo")
+	uri := ParseLocalFileToURI(file)
+
+	h := &LangHandler{
+		RootPath: base,
+		configs: map[string][]types.Language{
+			"vim": {
+				{
+					LintCommand:        `echo ` + file + `:2:1:bad word`,
+					LintFormats:        []string{"%f:%l:%c:%m"},
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
+					LintSource:         "vint",
+					FixCommand:         `sed s/bad/good/`,
+				},
+			},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "vim",
+				Text:               "ok\nbad\n",
+				NormalizedFilename: file,
+				Uri:                uri,
+			},
+		},
+	}
+	_, err := h.getAllDiagnosticsForUri(t, uri)
+	assert.NoError(t, err)
+
+	onDiagnostic := types.Range{Start: types.Position{Line: 1, Character: 1}, End: types.Position{Line: 1, Character: 1}}
+	elsewhere := types.Range{Start: types.Position{Line: 0, Character: 0}, End: types.Position{Line: 0, Character: 1}}
+	expectedEdits := []types.TextEdit{{
+		Range:   types.Range{Start: types.Position{Line: 1, Character: 0}, End: types.Position{Line: 2, Character: 0}},
+		NewText: "good\n",
+	}}
+
+	tests := []struct {
+		name          string
+		rng           types.Range
+		only          []types.CodeActionKind
+		expectedKinds []types.CodeActionKind
+	}{
+		{"on diagnostic", onDiagnostic, nil, []types.CodeActionKind{types.CodeActionQuickFix, "source.fixAll.vint"}},
+		{"no diagnostics in range", elsewhere, nil, nil},
+		{"only quickfix", onDiagnostic, []types.CodeActionKind{types.CodeActionQuickFix}, []types.CodeActionKind{types.CodeActionQuickFix}},
+		{"quickfix without diagnostics", elsewhere, []types.CodeActionKind{types.CodeActionQuickFix}, nil},
+		{"source requested explicitly", elsewhere, []types.CodeActionKind{types.CodeActionSource}, []types.CodeActionKind{"source.fixAll.vint"}},
+		{"fixAll for this tool", elsewhere, []types.CodeActionKind{"source.fixAll.vint"}, []types.CodeActionKind{"source.fixAll.vint"}},
+		{"fixAll for other tool", elsewhere, []types.CodeActionKind{"source.fixAll.eslint"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions, err := h.CodeActions(t.Context(), uri, tt.rng, tt.only)
+			assert.NoError(t, err)
+
+			kinds := make([]types.CodeActionKind, 0)
+			for _, a := range actions {
+				kinds = append(kinds, a.Kind)
+				assert.Equal(t, expectedEdits, a.Edit.Changes[uri])
+				if a.Kind == types.CodeActionQuickFix {
+					assert.Len(t, a.Diagnostics, 1)
+				}
+			}
+			assert.ElementsMatch(t, tt.expectedKinds, kinds)
+		})
+	}
+}
+
+func TestCodeActionsNothingToFix(t *testing.T) {
+	uri := types.DocumentURI("file:///foo")
+	h := &LangHandler{
+		configs: map[string][]types.Language{
+			"vim": {{FixCommand: "cat"}},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {LanguageID: "vim", Text: "ok\n", NormalizedFilename: "/foo", Uri: uri},
+		},
+	}
+
+	actions, err := h.CodeActions(t.Context(), uri, types.Range{}, []types.CodeActionKind{types.CodeActionSourceFixAll})
+	assert.NoError(t, err)
+	assert.Empty(t, actions)
+}
+
+func TestToolName(t *testing.T) {
+	assert.Equal(t, "ruff", toolName(types.Language{}, "/usr/bin/ruff check --fix -"))
+	assert.Equal(t, "eslint", toolName(types.Language{LintSource: "eslint"}, "eslint_d --fix-to-stdout"))
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// CommandNames lists the names of the configured commands.
+func (h *LangHandler) CommandNames() []string {
+	commands := h.configuredCommands()
+	names := make([]string, 0, len(commands))
+	for _, c := range commands {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// ExecuteCommand runs a configured command, with the document, if given, on stdin.
+// Depending on the command output setting, it returns an edit replacing the document with the output,
+// a message with the output to show, or nothing.
+func (h *LangHandler) ExecuteCommand(ctx context.Context, name string, uri types.DocumentURI) (*types.ApplyWorkspaceEditParams, string, error) {
+	commands := h.configuredCommands()
+	i := slices.IndexFunc(commands, func(c types.Command) bool { return c.Name == name })
+	if i < 0 {
+		return nil, "", fmt.Errorf("command not found: %v", name)
+	}
+	command := commands[i]
+
+	var f fileRef
+	if uri != "" {
+		var ok bool
+		f, ok = h.getFile(uri)
+		if !ok {
+			return nil, "", fmt.Errorf("document not found: %v", uri)
+		}
+	} else if command.Output == types.CommandOutputApply {
+		return nil, "", fmt.Errorf("command %v needs a document to apply its output to", name)
+	}
+
+	rootPath := h.RootPath
+	if f.NormalizedFilename != "" {
+		rootPath = h.findRootPath(f.NormalizedFilename, types.Language{})
+	}
+	cmdStr := replaceMagicStrings(command.Command, f.NormalizedFilename, rootPath)
+	cmd := buildExecCmd(ctx, cmdStr, rootPath, f.Text, types.Language{Env: command.Env}, true)
+	out, err := runFormattingCommand(cmd)
+
+	logs.Log.Logln(logs.Info, cmdStr)
+	logs.Log.Logln(logs.Debug, out)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("command error: %s", err)
+	}
+	out = strings.ReplaceAll(out, carriageReturn, "")
+
+	switch command.Output {
+	case types.CommandOutputApply:
+		edits, err := ComputeEdits(uri, f.Text, out)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(edits) == 0 {
+			return nil, "", nil
+		}
+		label := command.Title
+		if label == "" {
+			label = command.Name
+		}
+		// versioned, so that the client rejects the edit if the document changed while the command ran
+		return &types.ApplyWorkspaceEditParams{
+			Label: label,
+			Edit: types.WorkspaceEdit{DocumentChanges: []types.TextDocumentEdit{{
+				TextDocument: types.VersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: types.TextDocumentIdentifier{URI: uri},
+					Version:                f.Version,
+				},
+				Edits: edits,
+			}}},
+		}, "", nil
+	case types.CommandOutputMessage:
+		return nil, strings.TrimSpace(out), nil
+	default:
+		return nil, "", nil
+	}
+}
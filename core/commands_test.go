@@ -0,0 +1,75 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+	base, _ := os.Getwd()
+	file := filepath.Join(base, "foo")
+	uri := ParseLocalFileToURI(file)
+
+	h := &LangHandler{
+		RootPath: base,
+		commands: []types.Command{
+			{Name: "upper", Title: "Uppercase", Command: "tr a-z A-Z", Output: types.CommandOutputApply},
+			{Name: "count", Command: "wc -l", Output: types.CommandOutputMessage},
+			{Name: "touch", Command: "cat"},
+			{Name: "fail", Command: "exit 1", Output: types.CommandOutputMessage},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "vim",
+				Text:               "ok\nbad\n",
+				NormalizedFilename: file,
+				Uri:                uri,
+				Version:            3,
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"upper", "count", "touch", "fail"}, h.CommandNames())
+
+	applyEdit, message, err := h.ExecuteCommand(t.Context(), "upper", uri)
+	assert.NoError(t, err)
+	assert.Empty(t, message)
+	assert.Equal(t, &types.ApplyWorkspaceEditParams{
+		Label: "Uppercase",
+		Edit: types.WorkspaceEdit{DocumentChanges: []types.TextDocumentEdit{{
+			TextDocument: types.VersionedTextDocumentIdentifier{
+				TextDocumentIdentifier: types.TextDocumentIdentifier{URI: uri},
+				Version:                3,
+			},
+			Edits: []types.TextEdit{{
+				Range:   types.Range{Start: types.Position{Line: 0}, End: types.Position{Line: 2}},
+				NewText: "OK\nBAD\n",
+			}},
+		}}},
+	}, applyEdit)
+
+	applyEdit, message, err = h.ExecuteCommand(t.Context(), "count", uri)
+	assert.NoError(t, err)
+	assert.Nil(t, applyEdit)
+	assert.Equal(t, "2", message)
+
+	applyEdit, message, err = h.ExecuteCommand(t.Context(), "touch", "")
+	assert.NoError(t, err)
+	assert.Nil(t, applyEdit)
+	assert.Empty(t, message)
+
+	_, _, err = h.ExecuteCommand(t.Context(), "upper", "")
+	assert.Error(t, err)
+	_, _, err = h.ExecuteCommand(t.Context(), "fail", uri)
+	assert.Error(t, err)
+	_, _, err = h.ExecuteCommand(t.Context(), "missing", uri)
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+const (
+	rowPlaceholder = "${ROW}"
+	colPlaceholder = "${COL}"
+)
+
+// Completion collects candidates from the completion commands of all tools of the document.
+func (h *LangHandler) Completion(ctx context.Context, uri types.DocumentURI, pos types.Position) ([]types.CompletionItem, error) {
+	f, ok := h.getFile(uri)
+	if !ok {
+		return nil, fmt.Errorf("document not found: %v", uri)
+	}
+
+	items := make([]types.CompletionItem, 0)
+	seen := make(map[types.CompletionItem]bool)
+	for _, config := range h.documentConfigs(f) {
+		if config.CompletionCommand == "" {
+			continue
+		}
+		if dir := matchRootPath(f.NormalizedFilename, config.RootMarkers); dir == "" && boolOrDefault(config.RequireMarker, false) {
+			continue
+		}
+
+		rootPath := h.findRootPath(f.NormalizedFilename, config)
+		candidates, err := completeDocument(ctx, rootPath, f, pos, h.positionEncoding, config)
+		if err != nil {
+			logs.Log.Logln(logs.Error, err.Error())
+			continue
+		}
+		for _, item := range candidates {
+			if !seen[item] {
+				seen[item] = true
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func completeDocument(ctx context.Context, rootPath string, f fileRef, pos types.Position, encoding types.PositionEncodingKind, config types.Language) ([]types.CompletionItem, error) {
+	pattern, err := compileCompletionPattern(config.CompletionPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdStr := completionCommandString(config.CompletionCommand, f, pos, encoding, rootPath)
+	cmd := buildExecCmd(ctx, cmdStr, rootPath, f.Text, config, true)
+	out, err := runFormattingCommand(cmd)
+
+	logs.Log.Logln(logs.Info, cmdStr)
+	logs.Log.Logln(logs.Debug, out)
+
+	if err != nil {
+		return nil, fmt.Errorf("completion error: %s", err)
+	}
+
+	return parseCompletionOutput(out, pattern), nil
+}
+
+// completionCommandString fills ${ROW} and ${COL} with the 1-based line and byte column of the cursor,
+// which is what most command line tools expect.
+func completionCommandString(command string, f fileRef, pos types.Position, encoding types.PositionEncodingKind, rootPath string) string {
+	line := lineAt(f.Text, pos.Line)
+	col := positionToOffset(line, types.Position{Character: pos.Character}, encoding)
+
+	command = strings.ReplaceAll(command, rowPlaceholder, strconv.Itoa(pos.Line+1))
+	command = strings.ReplaceAll(command, colPlaceholder, strconv.Itoa(col+1))
+	return replaceMagicStrings(command, f.NormalizedFilename, rootPath)
+}
+
+func compileCompletionPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid completion pattern %q: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// parseCompletionOutput turns every non-empty line into a candidate.
+// With a pattern, lines that do not match are skipped and the label is taken from the "label" group,
+// or from the first group, or from the whole match. The "detail" group is optional.
+func parseCompletionOutput(out string, pattern *regexp.Regexp) []types.CompletionItem {
+	items := make([]types.CompletionItem, 0)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), carriageReturn)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if pattern == nil {
+			items = append(items, types.CompletionItem{Label: line, Kind: types.CompletionItemKindText})
+			continue
+		}
+
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		item := types.CompletionItem{Label: match[0], Kind: types.CompletionItemKindText}
+		if pattern.NumSubexp() > 0 {
+			item.Label = match[1]
+		}
+		if i := pattern.SubexpIndex("label"); i >= 0 {
+			item.Label = match[i]
+		}
+		if i := pattern.SubexpIndex("detail"); i >= 0 {
+			item.Detail = match[i]
+		}
+		if item.Label != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCompletionOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		pattern  string
+		expected []types.CompletionItem
+	}{
+		{
+			"lines",
+			"foo\n\nbar\r\n",
+			"",
+			[]types.CompletionItem{
+				{Label: "foo", Kind: types.CompletionItemKindText},
+				{Label: "bar", Kind: types.CompletionItemKindText},
+			},
+		},
+		{
+			"first group",
+			"foo\tsrc/a.go\nbar\tsrc/b.go\n",
+			`^(\S+)\t`,
+			[]types.CompletionItem{
+				{Label: "foo", Kind: types.CompletionItemKindText},
+				{Label: "bar", Kind: types.CompletionItemKindText},
+			},
+		},
+		{
+			"named groups",
+			"!_TAG_FILE_FORMAT\t2\nfoo\tsrc/a.go\tf\n",
+			`^(?P<label>[^!\t]\S*)\t(?P<detail>\S+)`,
+			[]types.CompletionItem{
+				{Label: "foo", Kind: types.CompletionItemKindText, Detail: "src/a.go"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pattern *regexp.Regexp
+			if tt.pattern != "" {
+				pattern = regexp.MustCompile(tt.pattern)
+			}
+			assert.Equal(t, tt.expected, parseCompletionOutput(tt.out, pattern))
+		})
+	}
+}
+
+func TestCompletionCommandString(t *testing.T) {
+	f := fileRef{Text: "ok\nżółw foo\n", NormalizedFilename: "/tmp/foo.txt"}
+	pos := types.Position{Line: 1, Character: 5}
+
+	tests := []struct {
+		encoding types.PositionEncodingKind
+		pos      types.Position
+	}{
+		{types.UTF16, pos},
+		{types.UTF32, pos},
+		{types.UTF8, types.Position{Line: 1, Character: 8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.encoding), func(t *testing.T) {
+			cmd := completionCommandString("complete ${INPUT} ${ROW} ${COL}", f, tt.pos, tt.encoding, "/tmp")
+			assert.Equal(t, "complete /tmp/foo.txt 2 9", cmd)
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+	base, _ := os.Getwd()
+	file := filepath.Join(base, "foo")
+	uri := ParseLocalFileToURI(file)
+
+	h := &LangHandler{
+		RootPath: base,
+		configs: map[string][]types.Language{
+			"vim": {
+				{CompletionCommand: `printf 'row${ROW}\ncol${COL}\n'`},
+				{CompletionCommand: `tr ' ' '\n'`},
+				{CompletionCommand: `exit 1`},
+			},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "vim",
+				Text:               "row2 abc",
+				NormalizedFilename: file,
+				Uri:                uri,
+			},
+		},
+	}
+
+	items, err := h.Completion(t.Context(), uri, types.Position{Line: 0, Character: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.CompletionItem{
+		{Label: "row1", Kind: types.CompletionItemKindText},
+		{Label: "col4", Kind: types.CompletionItemKindText},
+		{Label: "row2", Kind: types.CompletionItemKindText},
+		{Label: "abc", Kind: types.CompletionItemKindText},
+	}, items)
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// LoadConfigFile reads a YAML or JSON configuration file. Keys are kebab-case as described by schema.json,
+// camelCase as in client settings also works, and durations are strings like 1s or nanoseconds.
+func LoadConfigFile(path string) (*types.Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := parseConfigFile(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// parseConfigFile decodes the file as client settings, after converting it with convertConfigValue.
+// JSON is valid YAML, so both are read the same way. Unknown keys are rejected.
+func parseConfigFile(b []byte) (*types.Config, error) {
+	var raw any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	converted, err := convertConfigValue(raw, reflect.TypeFor[types.Config]())
+	if err != nil {
+		return nil, err
+	}
+	if settings, ok := converted.(map[string]any); ok {
+		if err := unknownConfigFields(settings); err != nil {
+			return nil, err
+		}
+	}
+	b, err = json.Marshal(converted)
+	if err != nil {
+		return nil, err
+	}
+
+	config := NewConfig()
+	if err := json.Unmarshal(b, config); err != nil {
+		return nil, err
+	}
+	// keys without a value, like "languages:", decode to nil
+	if config.Languages == nil {
+		config.Languages = NewConfig().Languages
+	}
+	if config.RootMarkers == nil {
+		config.RootMarkers = NewConfig().RootMarkers
+	}
+	return config, nil
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// convertConfigValue converts keys of objects decoded into structs from kebab-case to the json names,
+// and parses durations, following the type the value is decoded into. Keys of maps, like language ids, are kept.
+func convertConfigValue(value any, typ reflect.Type) (any, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		converted := make(map[string]any, len(v))
+		for key, elem := range v {
+			var elemType reflect.Type
+			switch typ.Kind() {
+			case reflect.Struct:
+				key = kebabToCamel(key)
+				if field, ok := jsonField(typ, key); ok {
+					elemType = field.Type
+				}
+			case reflect.Map:
+				elemType = typ.Elem()
+			}
+			if elemType == nil {
+				// unknown keys are reported by unknownConfigFields
+				converted[key] = elem
+				continue
+			}
+			c, err := convertConfigValue(elem, elemType)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			converted[key] = c
+		}
+		return converted, nil
+	case []any:
+		if typ.Kind() != reflect.Slice {
+			return v, nil
+		}
+		converted := make([]any, 0, len(v))
+		for i, elem := range v {
+			c, err := convertConfigValue(elem, typ.Elem())
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", i, err)
+			}
+			converted = append(converted, c)
+		}
+		return converted, nil
+	case string:
+		if typ != durationType {
+			return v, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		return int64(d), nil
+	}
+	return value, nil
+}
+
+// jsonField returns the field of the struct with the given json name.
+func jsonField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func kebabToCamel(key string) string {
+	parts := strings.Split(key, "-")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
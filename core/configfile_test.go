@@ -0,0 +1,151 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	lintOnSave := false
+	languages := map[string][]types.Language{
+		"docker-compose": {{
+			LintCommand:     "yamllint -f parsable -",
+			LintStdin:       boolPtr(true),
+			LintFormats:     []string{"%f:%l:%c: [%t%*[a-z]] %m"},
+			LintCategoryMap: map[string]string{"warn-ing": "W"},
+			LintOnSave:      &lintOnSave,
+		}},
+		"go": {{FormatCommand: "gofmt", FormatCanRange: boolPtr(true)}},
+	}
+	rootMarkers := []string{".git/"}
+	commands := []types.Command{{Name: "tidy", Command: "go mod tidy", Output: types.CommandOutputMessage}}
+	expected := &types.Config{
+		Languages:           &languages,
+		RootMarkers:         &rootMarkers,
+		LintDebounce:        500 * time.Millisecond,
+		FormatOnSaveTimeout: 2 * time.Second,
+		FormatDebounce:      100,
+		Commands:            &commands,
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"yaml", `
+root-markers: [.git/]
+lint-debounce: 500ms
+format-on-save-timeout: 2s
+format-debounce: 100
+commands:
+  - name: tidy
+    command: go mod tidy
+    output: message
+languages:
+  docker-compose:
+    - lint-command: yamllint -f parsable -
+      lint-stdin: true
+      lint-formats:
+        - "%f:%l:%c: [%t%*[a-z]] %m"
+      lint-category-map:
+        warn-ing: W
+      lint-on-save: false
+  go:
+    - format-command: gofmt
+      format-can-range: true
+`},
+		{"json with camelCase keys", `{
+  "rootMarkers": [".git/"],
+  "lint-debounce": "500ms",
+  "formatOnSaveTimeout": "2s",
+  "formatDebounce": 100,
+  "commands": [{"name": "tidy", "command": "go mod tidy", "output": "message"}],
+  "languages": {
+    "docker-compose": [{
+      "lintCommand": "yamllint -f parsable -",
+      "lint-stdin": true,
+      "lintFormats": ["%f:%l:%c: [%t%*[a-z]] %m"],
+      "lintCategoryMap": {"warn-ing": "W"},
+      "lintOnSave": false
+    }],
+    "go": [{"formatCommand": "gofmt", "formatCanRange": true}]
+  }
+}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			config, err := LoadConfigFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, config)
+		})
+	}
+}
+
+func TestLoadConfigFileWithEmptyKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("languages:\nroot-markers:\ntools:\ncommands:\n"), 0o644))
+
+	config, err := LoadConfigFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, NewConfig(), config)
+
+	h := NewHandler(config)
+	assert.Empty(t, h.languageConfigs())
+	assert.Empty(t, h.configuredRootMarkers())
+
+	// a handler can also be created from a config without them
+	assert.Empty(t, NewHandler(&types.Config{}).languageConfigs())
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid duration", "lint-debounce: soon"},
+		{"invalid yaml", "languages: [\n"},
+		{"wrong type", "languages: {go: {format-command: gofmt}}"},
+		{"unknown field", "languages: {go: [{format-comand: gofmt}]}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseConfigFile([]byte(tt.content))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := LoadConfigFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestClientSettingsLayeredOnConfigFile(t *testing.T) {
+	languages := map[string][]types.Language{"go": {{FormatCommand: "gofmt"}}, "sh": {{LintCommand: "shellcheck -"}}}
+	commands := []types.Command{{Name: "build", Command: "make"}, {Name: "test", Command: "make test"}}
+	config := NewConfig()
+	config.Languages = &languages
+	config.Commands = &commands
+	h := NewHandler(config)
+
+	clientLanguages := map[string][]types.Language{"go": {{FormatCommand: "goimports"}}}
+	clientCommands := []types.Command{{Name: "test", Command: "go test ./..."}}
+	h.UpdateConfiguration(&types.Config{Languages: &clientLanguages, Commands: &clientCommands})
+
+	assert.Equal(t, map[string][]types.Language{
+		"go": {{FormatCommand: "goimports"}},
+		"sh": {{LintCommand: "shellcheck -"}},
+	}, h.languageConfigs())
+	assert.Equal(t, []types.Command{{Name: "build", Command: "make"}, {Name: "test", Command: "go test ./..."}}, h.configuredCommands())
+
+	// languages the client no longer sets come back from the file
+	clientLanguages = map[string][]types.Language{}
+	h.UpdateConfiguration(&types.Config{Languages: &clientLanguages})
+	assert.Equal(t, languages, h.languageConfigs())
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// scopedConfig is a configuration, with tool definitions resolved, together with the directory
+// its workspace tools run in when no document points to a root.
+type scopedConfig struct {
+	rootPath string
+	configs  map[string][]types.Language
+}
+
+// UpdateFolderConfiguration sets the languages used for documents in the workspace folder.
+// Other settings are global. Without languages, the folder uses the global ones again.
+func (h *LangHandler) UpdateFolderConfiguration(folder string, config *types.Config) error {
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	folder = filepath.Clean(folder)
+	if config == nil || config.Languages == nil {
+		delete(h.folderLanguages, folder)
+		delete(h.folderConfigs, folder)
+		return nil
+	}
+	if h.folderLanguages == nil {
+		h.folderLanguages = make(map[string]map[string][]types.Language)
+	}
+	h.folderLanguages[folder] = layerMap(h.base.Languages, *config.Languages)
+	return errors.Join(h.resolveFolderConfigs(folder)...)
+}
+
+// resolveConfigs resolves the global and folder languages with the current tool definitions, so that tools are
+// resolved and validated once per update. It returns the problems of the global languages, those of a folder are
+// returned when its configuration is updated. Project configurations are resolved again when next used.
+// The caller must hold configMu.
+func (h *LangHandler) resolveConfigs() []error {
+	configs, errs := resolveLanguages(h.languages, h.tools)
+	h.configs = configs
+	for folder := range h.folderLanguages {
+		h.resolveFolderConfigs(folder)
+	}
+	h.projects.reset()
+	return errs
+}
+
+// resolveFolderConfigs expects the caller to hold configMu.
+func (h *LangHandler) resolveFolderConfigs(folder string) []error {
+	configs, errs := resolveLanguages(h.folderLanguages[folder], h.tools)
+	if h.folderConfigs == nil {
+		h.folderConfigs = make(map[string]map[string][]types.Language)
+	}
+	h.folderConfigs[folder] = configs
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("folder %s, %w", folder, err)
+	}
+	return errs
+}
+
+// layerMap returns the base entries with those configured replacing them per key, like languages per language id.
+func layerMap[V any](base *map[string]V, configured map[string]V) map[string]V {
+	if base == nil || len(*base) == 0 {
+		return configured
+	}
+	layered := maps.Clone(*base)
+	maps.Copy(layered, configured)
+	return layered
+}
+
+// layerCommands returns the base commands with those configured replacing them by name.
+func layerCommands(base *[]types.Command, commands []types.Command) []types.Command {
+	if base == nil || len(*base) == 0 {
+		return commands
+	}
+	layered := slices.DeleteFunc(slices.Clone(*base), func(b types.Command) bool {
+		return slices.ContainsFunc(commands, func(c types.Command) bool { return c.Name == b.Name })
+	})
+	return append(layered, commands...)
+}
+
+// languageConfigs returns the global languages, with tool definitions resolved.
+func (h *LangHandler) languageConfigs() map[string][]types.Language {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.configs
+}
+
+func (h *LangHandler) configuredRootMarkers() []string {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.rootMarkers
+}
+
+func (h *LangHandler) configuredCommands() []types.Command {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.commands
+}
+
+// configsFor returns the languages configured for the file: languages of the innermost workspace folder
+// that contains it and has its own, or the global ones, with those of its project configuration file on top.
+func (h *LangHandler) configsFor(fname string) map[string][]types.Language {
+	root := h.projectRoot(fname)
+
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+
+	configs := h.clientConfigsFor(fname)
+	if root != "" {
+		if project, ok := h.projects.config(root, h.tools); ok {
+			return layerMap(&configs, project.languages)
+		}
+	}
+	return configs
+}
+
+// documentConfigs returns the tools configured for the document.
+func (h *LangHandler) documentConfigs(f fileRef) []types.Language {
+	return getAllConfigsForLang(h.configsFor(f.NormalizedFilename), f.LanguageID)
+}
+
+// clientConfigsFor expects the caller to hold configMu.
+func (h *LangHandler) clientConfigsFor(fname string) map[string][]types.Language {
+	configs, depth := h.configs, -1
+	for folder, folderConfigs := range h.folderConfigs {
+		if inFolder(fname, folder) && len(folder) > depth {
+			configs, depth = folderConfigs, len(folder)
+		}
+	}
+	return configs
+}
+
+// scopedConfigs returns the configuration of each workspace folder, its own languages or the global ones,
+// followed by those of the projects with a configuration file that were seen so far.
+func (h *LangHandler) scopedConfigs() []scopedConfig {
+	projects := h.projects.roots()
+
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+
+	scopes := make([]scopedConfig, 0)
+	folders := h.workspaceFolders()
+	if len(folders) == 0 {
+		scopes = append(scopes, scopedConfig{rootPath: h.RootPath, configs: h.configs})
+	}
+	for _, folder := range folders {
+		configs, ok := h.folderConfigs[folder]
+		if !ok {
+			configs = h.configs
+		}
+		scopes = append(scopes, scopedConfig{rootPath: folder, configs: configs})
+	}
+	for _, root := range projects {
+		configs := h.clientConfigsFor(root)
+		if project, ok := h.projects.config(root, h.tools); ok {
+			scopes = append(scopes, scopedConfig{rootPath: root, configs: layerMap(&configs, project.languages)})
+		}
+	}
+	return scopes
+}
+
+func inFolder(fname, folder string) bool {
+	rel, err := filepath.Rel(filepath.FromSlash(folder), filepath.FromSlash(fname))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// WorkspaceFolders returns the folders open in the client, sorted.
+func (h *LangHandler) WorkspaceFolders() []string {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.workspaceFolders()
+}
+
+// workspaceFolders expects the caller to hold configMu. Clients that do not support workspace folders
+// only have the root.
+func (h *LangHandler) workspaceFolders() []string {
+	if len(h.folders) == 0 && h.RootPath != "" {
+		return []string{h.RootPath}
+	}
+	folders := slices.Clone(h.folders)
+	slices.Sort(folders)
+	return folders
+}
+
+// UpdateWorkspaceFolders adds and removes workspace folders. Removed folders lose their own languages.
+func (h *LangHandler) UpdateWorkspaceFolders(event types.WorkspaceFoldersChangeEvent) error {
+	added, err := folderPaths(event.Added)
+	if err != nil {
+		return err
+	}
+	removed, err := folderPaths(event.Removed)
+	if err != nil {
+		return err
+	}
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	h.folders = slices.DeleteFunc(h.folders, func(folder string) bool { return slices.Contains(removed, folder) })
+	for _, folder := range removed {
+		delete(h.folderLanguages, folder)
+		delete(h.folderConfigs, folder)
+	}
+	for _, folder := range added {
+		if !slices.Contains(h.folders, folder) {
+			h.folders = append(h.folders, folder)
+		}
+	}
+	return nil
+}
+
+// folderOf returns the innermost workspace folder that contains the file.
+func (h *LangHandler) folderOf(fname string) string {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+
+	var innermost string
+	for _, folder := range h.workspaceFolders() {
+		if inFolder(fname, folder) && len(folder) > len(innermost) {
+			innermost = folder
+		}
+	}
+	return innermost
+}
+
+func folderPaths(folders []types.WorkspaceFolder) ([]string, error) {
+	paths := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		path, err := PathFromURI(folder.URI)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, filepath.Clean(path))
+	}
+	return paths, nil
+}
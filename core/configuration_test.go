@@ -0,0 +1,103 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFolderConfiguration(t *testing.T) {
+	root := t.TempDir()
+	global := map[string][]types.Language{"go": {{LintCommand: "global"}}}
+	outer := map[string][]types.Language{"go": {{LintCommand: "outer"}}}
+	inner := map[string][]types.Language{"go": {{LintCommand: "inner"}}}
+
+	h := &LangHandler{RootPath: root, configs: global, folders: []string{filepath.Join(root, "a", "b"), filepath.Join(root, "a"), root}}
+	h.UpdateFolderConfiguration(filepath.Join(root, "a"), &types.Config{Languages: &outer})
+	h.UpdateFolderConfiguration(filepath.Join(root, "a", "b"), &types.Config{Languages: &inner})
+
+	tests := []struct {
+		name     string
+		file     string
+		expected map[string][]types.Language
+	}{
+		{"outside folders", filepath.Join(root, "main.go"), global},
+		{"sibling with common prefix", filepath.Join(root, "ab", "main.go"), global},
+		{"in folder", filepath.Join(root, "a", "main.go"), outer},
+		{"innermost folder", filepath.Join(root, "a", "b", "c", "main.go"), inner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, languagesFor(h, filepath.ToSlash(tt.file)))
+		})
+	}
+
+	assert.Equal(t, []scopedConfig{
+		{rootPath: root, configs: global},
+		{rootPath: filepath.Join(root, "a"), configs: outer},
+		{rootPath: filepath.Join(root, "a", "b"), configs: inner},
+	}, h.scopedConfigs())
+
+	// without languages the folder falls back to the global ones
+	h.UpdateFolderConfiguration(filepath.Join(root, "a", "b"), &types.Config{})
+	assert.Equal(t, outer, languagesFor(h, filepath.ToSlash(filepath.Join(root, "a", "b", "main.go"))))
+}
+
+func TestWorkspaceFolders(t *testing.T) {
+	base := t.TempDir()
+	first := filepath.Join(base, "first")
+	second := filepath.Join(base, "second")
+	languages := map[string][]types.Language{"go": {{LintCommand: "second"}}}
+
+	h := NewHandler(NewConfig())
+	_, err := h.Initialize(types.InitializeParams{
+		WorkspaceFolders: []types.WorkspaceFolder{{URI: ParseLocalFileToURI(first)}, {URI: ParseLocalFileToURI(second)}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, first, h.RootPath)
+	assert.Equal(t, []string{first, second}, h.WorkspaceFolders())
+
+	// documents fall back to their folder when no root marker matches
+	file := filepath.ToSlash(filepath.Join(second, "pkg", "main.go"))
+	assert.Equal(t, second, h.findRootPath(file, types.Language{}))
+	assert.Equal(t, first, h.findRootPath(filepath.ToSlash(filepath.Join(base, "main.go")), types.Language{}))
+
+	h.UpdateFolderConfiguration(second, &types.Config{Languages: &languages})
+	assert.Equal(t, languages, languagesFor(h, file))
+
+	third := filepath.Join(base, "third")
+	err = h.UpdateWorkspaceFolders(types.WorkspaceFoldersChangeEvent{
+		Added:   []types.WorkspaceFolder{{URI: ParseLocalFileToURI(third)}},
+		Removed: []types.WorkspaceFolder{{URI: ParseLocalFileToURI(second)}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first, third}, h.WorkspaceFolders())
+	assert.Equal(t, first, h.findRootPath(file, types.Language{}))
+	assert.Empty(t, languagesFor(h, file))
+}
+
+func TestWorkspaceFoldersAddedToRoot(t *testing.T) {
+	base := t.TempDir()
+	added := filepath.Join(base, "added")
+	file := filepath.ToSlash(filepath.Join(base, "main.go"))
+	languages := map[string][]types.Language{"go": {{LintCommand: "root"}}}
+
+	h := NewHandler(NewConfig())
+	_, err := h.Initialize(types.InitializeParams{RootURI: ParseLocalFileToURI(base)})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{base}, h.WorkspaceFolders())
+	assert.NoError(t, h.UpdateFolderConfiguration(base, &types.Config{Languages: &languages}))
+
+	// the root stays a folder once others are added
+	err = h.UpdateWorkspaceFolders(types.WorkspaceFoldersChangeEvent{Added: []types.WorkspaceFolder{{URI: ParseLocalFileToURI(added)}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{base, added}, h.WorkspaceFolders())
+	assert.Equal(t, base, h.findRootPath(file, types.Language{}))
+	assert.Equal(t, languages, languagesFor(h, file))
+}
+
+func languagesFor(h *LangHandler, fname string) map[string][]types.Language {
+	return h.configsFor(fname)
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// diagnosticsStore keeps the last diagnostics reported by each tool for each document,
+// so that results of a single tool can be replaced without dropping the results of others.
+// set, retain and setWorkspaceURIs expect mu to be held by the caller, so that the merged result can be
+// published before another tool modifies it.
+type diagnosticsStore struct {
+	mu    sync.Mutex
+	byURI map[types.DocumentURI]map[string][]types.Diagnostic
+	// result ids of the last pull diagnostics report, reset whenever diagnostics change
+	resultIDs map[types.DocumentURI]string
+	// files reported by the last run of each workspace linter
+	workspaceURIs map[string][]types.DocumentURI
+	// tools of workspace linters, whose results do not depend on the document being open
+	workspaceTools map[string]bool
+}
+
+// set replaces diagnostics of the given tool and returns the merged diagnostics of all tools for the uri.
+func (s *diagnosticsStore) set(uri types.DocumentURI, tool string, diagnostics []types.Diagnostic) []types.Diagnostic {
+	if s.byURI == nil {
+		s.byURI = make(map[types.DocumentURI]map[string][]types.Diagnostic)
+	}
+	byTool, ok := s.byURI[uri]
+	if !ok {
+		byTool = make(map[string][]types.Diagnostic)
+		s.byURI[uri] = byTool
+	}
+	byTool[tool] = diagnostics
+	delete(s.resultIDs, uri)
+	return s.merged(uri)
+}
+
+// retain drops diagnostics of tools that are not in the given list and returns the merged diagnostics for the uri,
+// and whether any were dropped.
+func (s *diagnosticsStore) retain(uri types.DocumentURI, tools []string) ([]types.Diagnostic, bool) {
+	var changed bool
+	for tool := range s.byURI[uri] {
+		if !slices.Contains(tools, tool) {
+			delete(s.byURI[uri], tool)
+			delete(s.resultIDs, uri)
+			changed = true
+		}
+	}
+	return s.merged(uri), changed
+}
+
+// setWorkspaceURIs records files reported by a workspace linter of the given tool and returns the files
+// it reported previously.
+func (s *diagnosticsStore) setWorkspaceURIs(key, tool string, uris []types.DocumentURI) []types.DocumentURI {
+	if s.workspaceURIs == nil {
+		s.workspaceURIs = make(map[string][]types.DocumentURI)
+		s.workspaceTools = make(map[string]bool)
+	}
+	s.workspaceTools[tool] = true
+	previous := s.workspaceURIs[key]
+	s.workspaceURIs[key] = uris
+	return previous
+}
+
+// workspaceFiles returns the files reported by the last run of a workspace linter.
+func (s *diagnosticsStore) workspaceFiles(key string) []types.DocumentURI {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.workspaceURIs[key])
+}
+
+func (s *diagnosticsStore) merged(uri types.DocumentURI) []types.Diagnostic {
+	byTool := s.byURI[uri]
+	tools := make([]string, 0, len(byTool))
+	for tool := range byTool {
+		tools = append(tools, tool)
+	}
+	// stable order, so that publishing the same results twice yields the same list
+	slices.Sort(tools)
+
+	diagnostics := make([]types.Diagnostic, 0)
+	for _, tool := range tools {
+		diagnostics = append(diagnostics, byTool[tool]...)
+	}
+	return diagnostics
+}
+
+func (s *diagnosticsStore) current(uri types.DocumentURI) []types.Diagnostic {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.merged(uri)
+}
+
+func (s *diagnosticsStore) forTool(uri types.DocumentURI, tool string) []types.Diagnostic {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.byURI[uri][tool])
+}
+
+func (s *diagnosticsStore) resultID(uri types.DocumentURI) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.resultIDs[uri]
+}
+
+func (s *diagnosticsStore) setResultID(uri types.DocumentURI, resultID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resultIDs == nil {
+		s.resultIDs = make(map[types.DocumentURI]string)
+	}
+	s.resultIDs[uri] = resultID
+}
+
+// delete drops diagnostics of the document when it is closed. Results of workspace linters are kept,
+// they are still reported by workspace diagnostics until the linter runs again.
+func (s *diagnosticsStore) delete(uri types.DocumentURI) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for tool := range s.byURI[uri] {
+		if !s.workspaceTools[tool] {
+			delete(s.byURI[uri], tool)
+		}
+	}
+	if len(s.byURI[uri]) == 0 {
+		delete(s.byURI, uri)
+	}
+	delete(s.resultIDs, uri)
+}
+
+// diagnosticsResultID identifies diagnostics of a document version produced by the given set of tools.
+func diagnosticsResultID(version int, tools []string) string {
+	tools = slices.Clone(tools)
+	slices.Sort(tools)
+
+	hash := fnv.New32a()
+	for _, tool := range tools {
+		_, _ = hash.Write([]byte(tool))
+		_, _ = hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%d-%x", version, hash.Sum32())
+}
+
+// lintToolKey identifies a linter within the diagnostics of a document. Entries running the same command
+// with different settings, like formats or source, are different linters. Only settings that change what
+// the linter reports count, so that editing other commands of the entry keeps its diagnostics.
+func lintToolKey(config types.Language) string {
+	b, err := json.Marshal(types.Language{
+		Env:                config.Env,
+		RootMarkers:        config.RootMarkers,
+		Prefix:             config.Prefix,
+		LintFormats:        config.LintFormats,
+		LintStdin:          config.LintStdin,
+		LintOffset:         config.LintOffset,
+		LintOffsetColumns:  config.LintOffsetColumns,
+		LintColumnUnit:     config.LintColumnUnit,
+		LintTabWidth:       config.LintTabWidth,
+		LintCommand:        config.LintCommand,
+		LintWorkspace:      config.LintWorkspace,
+		LintIgnoreExitCode: config.LintIgnoreExitCode,
+		LintCategoryMap:    config.LintCategoryMap,
+		LintSource:         config.LintSource,
+		LintSeverity:       config.LintSeverity,
+	})
+	if err != nil {
+		return config.LintCommand
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write(b)
+	return fmt.Sprintf("%s\x00%x", config.LintCommand, hash.Sum32())
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticsStore(t *testing.T) {
+	uri := types.DocumentURI("file:///foo")
+	var s diagnosticsStore
+
+	s.set(uri, "b", []types.Diagnostic{{Message: "b1"}})
+	merged := s.set(uri, "a", []types.Diagnostic{{Message: "a1"}, {Message: "a2"}})
+	assert.Equal(t, []types.Diagnostic{{Message: "a1"}, {Message: "a2"}, {Message: "b1"}}, merged)
+
+	merged = s.set(uri, "a", nil)
+	assert.Equal(t, []types.Diagnostic{{Message: "b1"}}, merged)
+
+	merged, changed := s.retain(uri, []string{"a"})
+	assert.Empty(t, merged)
+	assert.True(t, changed)
+
+	_, changed = s.retain(uri, []string{"a"})
+	assert.False(t, changed)
+
+	s.set(uri, "a", []types.Diagnostic{{Message: "a1"}})
+	s.delete(uri)
+	assert.Empty(t, s.merged(uri))
+}
+
+func TestLintToolKey(t *testing.T) {
+	eslint := types.Language{LintCommand: "eslint ${INPUT}", LintSource: "eslint"}
+	assert.Equal(t, lintToolKey(eslint), lintToolKey(eslint))
+	assert.NotEqual(t, lintToolKey(eslint), lintToolKey(types.Language{LintCommand: "eslint ${INPUT}", LintSource: "eslint-strict"}))
+	assert.NotEqual(t, lintToolKey(eslint), lintToolKey(types.Language{LintCommand: "eslint ${INPUT}"}))
+
+	// settings of other commands and of when the linter runs do not matter
+	edited := eslint
+	edited.FormatCommand = "prettier ${INPUT}"
+	edited.FixCommand = "eslint --fix-dry-run ${INPUT}"
+	edited.LintOnChange = boolPtr(false)
+	assert.Equal(t, lintToolKey(eslint), lintToolKey(edited))
+}
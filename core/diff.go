@@ -5,6 +5,9 @@ import (
 	"github.com/konradmalik/flint-ls/types"
 )
 
+// ComputeEdits returns edits that turn before into after.
+// Edits always span whole lines, so every position has character 0
+// and is valid regardless of the negotiated position encoding.
 func ComputeEdits(name types.DocumentURI, before, after string) ([]types.TextEdit, error) {
 	edits := udiff.Strings(before, after)
 	d, err := udiff.ToUnifiedDiff(string(name), string(name), before, edits, 0)
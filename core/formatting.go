@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/konradmalik/flint-ls/logs"
@@ -21,12 +22,13 @@ var (
 )
 
 func (h *LangHandler) RunAllFormatters(ctx context.Context, uri types.DocumentURI, rng *types.Range, options types.FormattingOptions) ([]types.TextEdit, error) {
-	f, ok := h.files[uri]
+	f, ok := h.getFile(uri)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %v", uri)
 	}
 
-	configs, err := getFormatConfigsForDocument(f.NormalizedFilename, f.LanguageID, h.configs)
+	allConfigs := h.configsFor(f.NormalizedFilename)
+	configs, err := getFormatConfigsForDocument(f.NormalizedFilename, f.LanguageID, allConfigs)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +37,31 @@ func (h *LangHandler) RunAllFormatters(ctx context.Context, uri types.DocumentUR
 		return nil, nil
 	}
 
+	return h.runFormatters(ctx, f, configs, rng, options)
+}
+
+// RunSaveFormatters runs formatters that opted in to formatting on save.
+func (h *LangHandler) RunSaveFormatters(ctx context.Context, uri types.DocumentURI) ([]types.TextEdit, error) {
+	f, ok := h.getFile(uri)
+	if !ok {
+		return nil, fmt.Errorf("document not found: %v", uri)
+	}
+
+	allConfigs := h.configsFor(f.NormalizedFilename)
+	configs, err := getFormatConfigsForDocument(f.NormalizedFilename, f.LanguageID, allConfigs)
+	if err != nil {
+		return nil, err
+	}
+	configs = slices.DeleteFunc(configs, func(cfg types.Language) bool { return !boolOrDefault(cfg.FormatOnSave, false) })
+	if len(configs) == 0 {
+		logs.Log.Logf(logs.Debug, "no format on save configs for LanguageID: %v", f.LanguageID)
+		return nil, nil
+	}
+
+	return h.runFormatters(ctx, f, configs, nil, nil)
+}
+
+func (h *LangHandler) runFormatters(ctx context.Context, f fileRef, configs []types.Language, rng *types.Range, options types.FormattingOptions) ([]types.TextEdit, error) {
 	originalText := f.Text
 	formattedText := originalText
 	formatted := false
@@ -42,7 +69,7 @@ func (h *LangHandler) RunAllFormatters(ctx context.Context, uri types.DocumentUR
 	errors := make([]string, 0)
 	for _, config := range configs {
 		rootPath := h.findRootPath(f.NormalizedFilename, config)
-		newText, err := formatDocument(ctx, rootPath, f.NormalizedFilename, formattedText, rng, options, config)
+		newText, err := formatDocument(ctx, rootPath, f.NormalizedFilename, formattedText, rng, h.positionEncoding, options, config)
 
 		if err != nil {
 			errors = append(errors, err.Error())
@@ -54,24 +81,29 @@ func (h *LangHandler) RunAllFormatters(ctx context.Context, uri types.DocumentUR
 		formattedText = newText
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if !formatted {
 		return nil, fmt.Errorf("could not format for LanguageID: %s. All errors: %v", f.LanguageID, errors)
 	}
 
 	logs.Log.Logln(logs.Info, "format succeeded")
-	return ComputeEdits(uri, originalText, formattedText)
+	return ComputeEdits(f.Uri, originalText, formattedText)
 }
 
 // this needs to accept textToFormat because in case we have multiple formatters, we can pass previous formatted text.
 // otherwise, we'd format the original file over and over.
-func formatDocument(ctx context.Context, rootPath string, filename string, textToFormat string, rng *types.Range, options types.FormattingOptions, config types.Language) (string, error) {
-	cmdStr, err := buildFormatCommandString(rootPath, filename, textToFormat, options, rng, config.FormatCommand)
+func formatDocument(ctx context.Context, rootPath string, filename string, textToFormat string, rng *types.Range, encoding types.PositionEncodingKind, options types.FormattingOptions, config types.Language) (string, error) {
+	cmdStr, err := buildFormatCommandString(rootPath, filename, textToFormat, options, rng, encoding, config.FormatCommand)
 	if err != nil {
 		return "", fmt.Errorf("command build error: %s", err)
 	}
 
 	cmd := buildExecCmd(ctx, cmdStr, rootPath, textToFormat, config, true)
+	done := traceTool(ctx, config, config.FormatCommand, filename)
 	out, err := runFormattingCommand(cmd)
+	done(err)
 
 	logs.Log.Logln(logs.Info, cmdStr)
 	logs.Log.Logln(logs.Debug, out)
@@ -123,24 +155,25 @@ func applyOptionsPlaceholders[T any](command string, options map[string]T) (stri
 	return strings.TrimSpace(command), nil
 }
 
-func applyRangePlaceholders(command string, rng *types.Range, text string) (string, error) {
-	lines := strings.Split(text, "\n")
-	charStart := convertRowColToIndex(lines, rng.Start.Line, rng.Start.Character)
-	charEnd := convertRowColToIndex(lines, rng.End.Line, rng.End.Character)
+// applyRangePlaceholders fills in the range in UTF-16 code units, like indices of JavaScript strings,
+// whatever position encoding the client negotiated, so that tools get the same offsets from every client.
+func applyRangePlaceholders(command string, rng *types.Range, text string, encoding types.PositionEncodingKind) (string, error) {
+	start := positionToOffset(text, rng.Start, encoding)
+	end := positionToOffset(text, rng.End, encoding)
 
 	rangeOptions := map[string]int{
-		"charStart": charStart,
-		"charEnd":   charEnd,
+		"charStart": encodedLen(text[:start], types.UTF16),
+		"charEnd":   encodedLen(text[:end], types.UTF16),
 		"rowStart":  rng.Start.Line,
-		"colStart":  rng.Start.Character,
+		"colStart":  encodedLen(text[lineStart(text, start):start], types.UTF16),
 		"rowEnd":    rng.End.Line,
-		"colEnd":    rng.End.Character,
+		"colEnd":    encodedLen(text[lineStart(text, end):end], types.UTF16),
 	}
 
 	return applyOptionsPlaceholders(command, rangeOptions)
 }
 
-func buildFormatCommandString(rootPath string, filename string, textToFormat string, options types.FormattingOptions, rng *types.Range, command string) (string, error) {
+func buildFormatCommandString(rootPath string, filename string, textToFormat string, options types.FormattingOptions, rng *types.Range, encoding types.PositionEncodingKind, command string) (string, error) {
 	command = replaceMagicStrings(command, filename, rootPath)
 
 	var err error
@@ -150,7 +183,7 @@ func buildFormatCommandString(rootPath string, filename string, textToFormat str
 	}
 
 	if rng != nil {
-		command, err = applyRangePlaceholders(command, rng, textToFormat)
+		command, err = applyRangePlaceholders(command, rng, textToFormat, encoding)
 		if err != nil {
 			return "", err
 		}
@@ -175,7 +208,7 @@ func getFormatConfigsForDocument(fname, langId string, allConfigs map[string][]t
 		if cfg.FormatCommand == "" {
 			continue
 		}
-		if dir := matchRootPath(fname, cfg.RootMarkers); dir == "" && cfg.RequireMarker {
+		if dir := matchRootPath(fname, cfg.RootMarkers); dir == "" && boolOrDefault(cfg.RequireMarker, false) {
 			continue
 		}
 
@@ -184,20 +217,3 @@ func getFormatConfigsForDocument(fname, langId string, allConfigs map[string][]t
 
 	return configs, nil
 }
-
-func convertRowColToIndex(lines []string, row, col int) int {
-	row = max(row, 0)
-	row = min(row, len(lines)-1)
-
-	col = max(col, 0)
-	col = min(col, len(lines[row]))
-
-	index := 0
-	for i := 0; i < row; i++ {
-		// Add the length of each line plus 1 for the newline character
-		index += len(lines[i]) + 1
-	}
-	index += col
-
-	return index
-}
@@ -1,11 +1,13 @@
 package core
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/konradmalik/flint-ls/types"
 	"github.com/stretchr/testify/assert"
@@ -56,17 +58,40 @@ func TestApplyRangePlaceholders(t *testing.T) {
 		End:   types.Position{Line: 0, Character: 4},
 	}
 	text := "abcdef"
-	out, err := applyRangePlaceholders(cmd, rng, text)
+	out, err := applyRangePlaceholders(cmd, rng, text, types.UTF16)
 	assert.NoError(t, err)
 	assert.Contains(t, out, "--flag 2")
 	assert.Contains(t, out, "--flag=4")
 }
 
+func TestApplyRangePlaceholders_Encodings(t *testing.T) {
+	cmd := "echo ${--start=charStart} ${--end=charEnd} ${--col=colStart}"
+	text := "é\n😊ab"
+	// the same range in every encoding, offsets are in utf-16 code units
+	tests := []struct {
+		encoding types.PositionEncodingKind
+		rng      *types.Range
+		expected string
+	}{
+		{types.UTF8, &types.Range{Start: types.Position{Line: 1, Character: 4}, End: types.Position{Line: 1, Character: 5}}, "echo --start=4 --end=5 --col=2"},
+		{types.UTF16, &types.Range{Start: types.Position{Line: 1, Character: 2}, End: types.Position{Line: 1, Character: 3}}, "echo --start=4 --end=5 --col=2"},
+		{types.UTF32, &types.Range{Start: types.Position{Line: 1, Character: 1}, End: types.Position{Line: 1, Character: 2}}, "echo --start=4 --end=5 --col=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.encoding), func(t *testing.T) {
+			out, err := applyRangePlaceholders(cmd, tt.rng, text, tt.encoding)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+		})
+	}
+}
+
 func TestBuildCommand_HandlesPlaceholders(t *testing.T) {
 	command := "echo ${flag:opt} ${anotherflag:tpo}"
 	opts := types.FormattingOptions{"opt": "value"}
 
-	cmdStr, err := buildFormatCommandString("/root", "file.txt", "text", opts, nil, command)
+	cmdStr, err := buildFormatCommandString("/root", "file.txt", "text", opts, nil, types.UTF16, command)
 
 	assert.NoError(t, err)
 
@@ -79,7 +104,7 @@ func TestFormatDocument_WithStdin(t *testing.T) {
 	cfg := types.Language{FormatCommand: "cat -"}
 	tmpDir := t.TempDir()
 
-	out, err := formatDocument(t.Context(), tmpDir, "file.txt", "hello text", nil, nil, cfg)
+	out, err := formatDocument(t.Context(), tmpDir, "file.txt", "hello text", nil, types.UTF16, nil, cfg)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "hello text", strings.TrimSpace(out))
@@ -96,7 +121,7 @@ func TestRunFormatters_Success(t *testing.T) {
 			types.DocumentURI("file://" + testfile): {Text: "hello", LanguageID: "go", NormalizedFilename: testfile},
 		},
 		configs: map[string][]types.Language{
-			"go": {{FormatCommand: "cat", RequireMarker: false}},
+			"go": {{FormatCommand: "cat", RequireMarker: boolPtr(false)}},
 		},
 	}
 	edits, err := h.RunAllFormatters(t.Context(), types.DocumentURI("file://"+testfile), nil, nil)
@@ -125,8 +150,8 @@ func TestRunFormatters_UsesPreviousText(t *testing.T) {
 		},
 		configs: map[string][]types.Language{
 			"go": {
-				{FormatCommand: cmd1, RequireMarker: false},
-				{FormatCommand: cmd2, RequireMarker: false},
+				{FormatCommand: cmd1, RequireMarker: boolPtr(false)},
+				{FormatCommand: cmd2, RequireMarker: boolPtr(false)},
 			},
 		},
 	}
@@ -146,7 +171,7 @@ func TestRunFormatters_RequireRootMatcher(t *testing.T) {
 			"vim": {
 				{
 					FormatCommand: `echo ` + filepath + `:2:No it is normal!`,
-					RequireMarker: true,
+					RequireMarker: boolPtr(true),
 					RootMarkers:   []string{".vimfmtrc"},
 				},
 			},
@@ -163,3 +188,58 @@ func TestRunFormatters_RequireRootMatcher(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, d)
 }
+
+func TestRunSaveFormatters_OnlyOptedIn(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+	tmpDir := t.TempDir()
+	testfile := filepath.Join(tmpDir, "text.txt")
+	uri := ParseLocalFileToURI(testfile)
+
+	h := &LangHandler{
+		files: map[types.DocumentURI]*fileRef{
+			uri: {Text: "hello", LanguageID: "go", NormalizedFilename: testfile},
+		},
+		configs: map[string][]types.Language{
+			"go": {
+				{FormatCommand: "echo \"$(cat -)onsave\"", FormatOnSave: boolPtr(true)},
+				{FormatCommand: "echo \"$(cat -)manual\""},
+			},
+		},
+	}
+	edits, err := h.RunSaveFormatters(t.Context(), uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "helloonsave\n", edits[0].NewText)
+
+	h.configs["go"][0].FormatOnSave = boolPtr(false)
+	edits, err = h.RunSaveFormatters(t.Context(), uri)
+	assert.NoError(t, err)
+	assert.Empty(t, edits)
+}
+
+func TestRunSaveFormatters_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+	tmpDir := t.TempDir()
+	testfile := filepath.Join(tmpDir, "text.txt")
+	uri := ParseLocalFileToURI(testfile)
+
+	h := &LangHandler{
+		files: map[types.DocumentURI]*fileRef{
+			uri: {Text: "hello", LanguageID: "go", NormalizedFilename: testfile},
+		},
+		configs: map[string][]types.Language{
+			"go": {{FormatCommand: "sleep 10; cat -", FormatOnSave: boolPtr(true)}},
+		},
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	edits, err := h.RunSaveFormatters(ctx, uri)
+	assert.Error(t, err)
+	assert.Empty(t, edits)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -1,19 +1,51 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/konradmalik/flint-ls/types"
 )
 
 type LangHandler struct {
-	configs     map[string][]types.Language
+	// guards the configuration, which is updated while tools run
+	configMu sync.RWMutex
+	// configuration the handler was created with, settings from the client are layered on top
+	base types.Config
+	// languages as configured, referring to tool definitions and presets
+	languages map[string][]types.Language
+	// languages with tool definitions and presets resolved and tools with problems left out,
+	// resolved whenever the configuration is updated
+	configs map[string][]types.Language
+	// tool definitions referred to by languages
+	tools map[string]types.Language
+	// languages configured for workspace folders, used instead of the global ones for documents in those folders,
+	// as configured and resolved
+	folderLanguages map[string]map[string][]types.Language
+	folderConfigs   map[string]map[string][]types.Language
+	// workspace folders open in the client, guarded by configMu
+	folders []string
+	// directories whose project configuration files are used
+	trustedProjects []string
+	// project configuration files found in roots
+	projects    projectConfigs
+	filesMu     sync.RWMutex
 	files       map[types.DocumentURI]*fileRef
 	RootPath    string
 	rootMarkers []string
+	commands    []types.Command
+	diagnostics diagnosticsStore
+	// in-flight and queued runs of workspace linters
+	workspaceRuns workspaceRuns
+	// output of workspace symbol commands by command and root
+	workspaceSymbols workspaceSymbolCache
+	// negotiated with the client during initialization, empty means utf-16
+	positionEncoding types.PositionEncodingKind
 }
 
 type fileRef struct {
@@ -35,10 +67,26 @@ func NewConfig() *types.Config {
 
 func NewHandler(config *types.Config) *LangHandler {
 	handler := &LangHandler{
-		configs:     *config.Languages,
-		files:       make(map[types.DocumentURI]*fileRef),
-		rootMarkers: *config.RootMarkers,
+		base:  *config,
+		files: make(map[types.DocumentURI]*fileRef),
 	}
+	if config.Languages != nil {
+		handler.languages = *config.Languages
+	}
+	if config.RootMarkers != nil {
+		handler.rootMarkers = *config.RootMarkers
+	}
+	if config.Commands != nil {
+		handler.commands = *config.Commands
+	}
+	if config.Tools != nil {
+		handler.tools = *config.Tools
+	}
+	if config.TrustedProjects != nil {
+		handler.trustedProjects = *config.TrustedProjects
+	}
+	// problems are reported when the configuration is applied with UpdateConfiguration
+	handler.resolveConfigs()
 	return handler
 }
 
@@ -50,51 +98,157 @@ func (h *LangHandler) Initialize(params types.InitializeParams) (types.Initializ
 		}
 		h.RootPath = filepath.Clean(rootPath)
 	}
+	folders, err := folderPaths(params.WorkspaceFolders)
+	if err != nil {
+		return types.InitializeResult{}, err
+	}
+	if h.RootPath == "" && len(folders) > 0 {
+		h.RootPath = folders[0]
+	}
+	if len(folders) == 0 && h.RootPath != "" {
+		// the root is the only folder, and stays one when folders are added later
+		folders = []string{h.RootPath}
+	}
+	h.configMu.Lock()
+	h.folders = folders
+	h.configMu.Unlock()
+
+	h.positionEncoding = negotiatePositionEncoding(params.Capabilities)
 
 	var hasFormatCommand bool
+	var hasFormatOnSave bool
 	var hasRangeFormatCommand bool
+	var hasFixCommand bool
+	var hasCompletionCommand bool
+	var hasSymbolCommand bool
+	var hasWorkspaceSymbolCommand bool
+	var hasWorkspaceLinter bool
 
 	if params.InitializationOptions != nil {
 		hasFormatCommand = params.InitializationOptions.DocumentFormatting
 		hasRangeFormatCommand = params.InitializationOptions.RangeFormatting
+		hasFixCommand = params.InitializationOptions.CodeAction
+		hasCompletionCommand = params.InitializationOptions.Completion
+		hasSymbolCommand = params.InitializationOptions.DocumentSymbol
+		hasWorkspaceSymbolCommand = params.InitializationOptions.WorkspaceSymbol
 	}
 
-	for _, config := range h.configs {
+	for _, config := range h.languageConfigs() {
 		for _, lang := range config {
+			if lang.FixCommand != "" {
+				hasFixCommand = true
+			}
+			if lang.CompletionCommand != "" {
+				hasCompletionCommand = true
+			}
+			if lang.SymbolCommand != "" {
+				hasSymbolCommand = true
+			}
+			if lang.WorkspaceSymbolCommand != "" {
+				hasWorkspaceSymbolCommand = true
+			}
+			if lang.LintCommand != "" && boolOrDefault(lang.LintWorkspace, false) {
+				hasWorkspaceLinter = true
+			}
 			if lang.FormatCommand != "" {
 				hasFormatCommand = true
-				if lang.FormatCanRange {
+				if boolOrDefault(lang.FormatCanRange, false) {
 					hasRangeFormatCommand = true
-					break
+				}
+				if boolOrDefault(lang.FormatOnSave, false) {
+					hasFormatOnSave = true
 				}
 			}
 		}
 	}
 
+	var codeActionProvider *types.CodeActionOptions
+	if hasFixCommand {
+		codeActionProvider = &types.CodeActionOptions{
+			CodeActionKinds: codeActionKinds,
+		}
+	}
+
+	var executeCommandProvider *types.ExecuteCommandOptions
+	if len(h.configuredCommands()) > 0 {
+		executeCommandProvider = &types.ExecuteCommandOptions{Commands: h.CommandNames()}
+	}
+
+	var completionProvider *types.CompletionOptions
+	if hasCompletionCommand {
+		completionProvider = &types.CompletionOptions{}
+	}
+
 	return types.InitializeResult{
 		Capabilities: types.ServerCapabilities{
-			PositionEncoding: types.UTF16,
+			PositionEncoding: h.positionEncoding,
 			TextDocumentSync: types.TextDocumentSyncOptions{
 				OpenClose: true,
-				Change:    types.TDSKFull,
+				Change:    types.TDSKIncremental,
+				// formatters opt in to formatting on save per tool
+				WillSaveWaitUntil: hasFormatOnSave,
 			},
 			DocumentFormattingProvider: hasFormatCommand,
 			RangeFormattingProvider:    hasRangeFormatCommand,
+			DiagnosticProvider:         &types.DiagnosticOptions{WorkspaceDiagnostics: hasWorkspaceLinter},
+			CodeActionProvider:         codeActionProvider,
+			HoverProvider:              true,
+			CompletionProvider:         completionProvider,
+			DocumentSymbolProvider:     hasSymbolCommand,
+			WorkspaceSymbolProvider:    hasWorkspaceSymbolCommand,
+			ExecuteCommandProvider:     executeCommandProvider,
+			Workspace: &types.WorkspaceServerCapabilities{
+				WorkspaceFolders: &types.WorkspaceFoldersServerCapabilities{Supported: true, ChangeNotifications: true},
+			},
 		},
 	}, nil
 }
 
-func (h *LangHandler) UpdateConfiguration(config *types.Config) {
+// negotiatePositionEncoding picks the cheapest encoding offered by the client.
+// Text is stored as utf-8, so utf-8 needs no conversion and utf-32 only needs to decode runes.
+// utf-16 is mandatory for every client and is used when nothing better is offered.
+func negotiatePositionEncoding(capabilities types.ClientCapabilities) types.PositionEncodingKind {
+	if capabilities.General == nil {
+		return types.UTF16
+	}
+	for _, preferred := range []types.PositionEncodingKind{types.UTF8, types.UTF32} {
+		if slices.Contains(capabilities.General.PositionEncodings, preferred) {
+			return preferred
+		}
+	}
+	return types.UTF16
+}
+
+// UpdateConfiguration layers the settings on top of the base configuration and returns the problems found in the
+// result. Tools with problems are not run.
+func (h *LangHandler) UpdateConfiguration(config *types.Config) error {
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
 	if config.Languages != nil {
-		h.configs = *config.Languages
+		h.languages = layerMap(h.base.Languages, *config.Languages)
+	}
+	if config.Tools != nil {
+		h.tools = layerMap(h.base.Tools, *config.Tools)
 	}
 	if config.RootMarkers != nil {
 		h.rootMarkers = *config.RootMarkers
 	}
+	if config.Commands != nil {
+		h.commands = layerCommands(h.base.Commands, *config.Commands)
+	}
+	if config.TrustedProjects != nil {
+		h.trustedProjects = *config.TrustedProjects
+	}
+	errs := h.resolveConfigs()
+	return errors.Join(append(errs, validateCommands(h.commands)...)...)
 }
 
 func (h *LangHandler) CloseFile(uri types.DocumentURI) error {
+	h.filesMu.Lock()
 	delete(h.files, uri)
+	h.filesMu.Unlock()
+	h.diagnostics.delete(uri)
 	return nil
 }
 
@@ -111,12 +265,36 @@ func (h *LangHandler) OpenFile(uri types.DocumentURI, languageID string, version
 		NormalizedFilename: fname,
 		Uri:                uri,
 	}
+	h.filesMu.Lock()
 	h.files[uri] = f
+	h.filesMu.Unlock()
+
+	return nil
+}
+
+// ApplyChanges applies incremental (or full) content changes to the stored document.
+func (h *LangHandler) ApplyChanges(uri types.DocumentURI, changes []types.TextDocumentContentChangeEvent, version int) error {
+	h.filesMu.Lock()
+	defer h.filesMu.Unlock()
+
+	f, ok := h.files[uri]
+	if !ok {
+		return fmt.Errorf("document not found: %v", uri)
+	}
+	text, err := applyContentChanges(f.Text, changes, h.positionEncoding)
+	if err != nil {
+		return fmt.Errorf("cannot apply changes to %v: %w", uri, err)
+	}
+	f.Text = text
+	f.Version = version
 
 	return nil
 }
 
 func (h *LangHandler) UpdateFile(uri types.DocumentURI, text string, version *int) error {
+	h.filesMu.Lock()
+	defer h.filesMu.Unlock()
+
 	f, ok := h.files[uri]
 	if !ok {
 		return fmt.Errorf("document not found: %v", uri)
@@ -129,13 +307,37 @@ func (h *LangHandler) UpdateFile(uri types.DocumentURI, text string, version *in
 	return nil
 }
 
+// getFile returns a snapshot of the document, safe to use while the document keeps changing.
+func (h *LangHandler) getFile(uri types.DocumentURI) (fileRef, bool) {
+	h.filesMu.RLock()
+	defer h.filesMu.RUnlock()
+
+	f, ok := h.files[uri]
+	if !ok {
+		return fileRef{}, false
+	}
+	return *f, true
+}
+
+// isCurrentVersion reports whether the document is still open and has not changed since the given version.
+func (h *LangHandler) isCurrentVersion(uri types.DocumentURI, version int) bool {
+	h.filesMu.RLock()
+	defer h.filesMu.RUnlock()
+
+	f, ok := h.files[uri]
+	return ok && f.Version == version
+}
+
 func (h *LangHandler) findRootPath(fname string, lang types.Language) string {
 	if dir := matchRootPath(fname, lang.RootMarkers); dir != "" {
 		return dir
 	}
-	if dir := matchRootPath(fname, h.rootMarkers); dir != "" {
+	if dir := matchRootPath(fname, h.configuredRootMarkers()); dir != "" {
 		return dir
 	}
+	if folder := h.folderOf(fname); folder != "" {
+		return folder
+	}
 
 	return h.RootPath
 }
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiatePositionEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		offered  *types.GeneralClientCapabilities
+		expected types.PositionEncodingKind
+	}{
+		{"nothing offered", nil, types.UTF16},
+		{"empty list", &types.GeneralClientCapabilities{}, types.UTF16},
+		{"only utf-16", &types.GeneralClientCapabilities{PositionEncodings: []types.PositionEncodingKind{types.UTF16}}, types.UTF16},
+		{"utf-8 preferred", &types.GeneralClientCapabilities{PositionEncodings: []types.PositionEncodingKind{types.UTF16, types.UTF8}}, types.UTF8},
+		{"utf-32 over utf-16", &types.GeneralClientCapabilities{PositionEncodings: []types.PositionEncodingKind{types.UTF16, types.UTF32}}, types.UTF32},
+		{"unknown ignored", &types.GeneralClientCapabilities{PositionEncodings: []types.PositionEncodingKind{"utf-7"}}, types.UTF16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(NewConfig())
+			res, err := h.Initialize(types.InitializeParams{Capabilities: types.ClientCapabilities{General: tt.offered}})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, res.Capabilities.PositionEncoding)
+			assert.Equal(t, tt.expected, h.positionEncoding)
+		})
+	}
+}
+
+func TestWillSaveWaitUntilFollowsFormatOnSave(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   types.Language
+		expected bool
+	}{
+		{"no formatter", types.Language{LintCommand: "vint -"}, false},
+		{"formatter", types.Language{FormatCommand: "gofmt"}, false},
+		{"formatter on save", types.Language{FormatCommand: "gofmt", FormatOnSave: boolPtr(true)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &LangHandler{configs: map[string][]types.Language{"go": {tt.config}}}
+			res, err := h.Initialize(types.InitializeParams{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, res.Capabilities.TextDocumentSync.WillSaveWaitUntil)
+		})
+	}
+}
+
+func TestWorkspaceDiagnosticsFollowWorkspaceLinters(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   types.Language
+		expected bool
+	}{
+		{"document linter", types.Language{LintCommand: "vint -"}, false},
+		{"workspace linter", types.Language{LintCommand: "golangci-lint run", LintWorkspace: boolPtr(true)}, true},
+		{"workspace without linter", types.Language{FormatCommand: "gofmt", LintWorkspace: boolPtr(true)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &LangHandler{configs: map[string][]types.Language{"go": {tt.config}}}
+			res, err := h.Initialize(types.InitializeParams{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, res.Capabilities.DiagnosticProvider.WorkspaceDiagnostics)
+		})
+	}
+}
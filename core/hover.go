@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+const codePlaceholder = "${CODE}"
+
+// Hover describes the diagnostics under the cursor.
+// Tools with an ExplainCommand also get its output for the code of each diagnostic, rendered as markdown.
+// Nil is returned when there are no diagnostics at the position.
+func (h *LangHandler) Hover(ctx context.Context, uri types.DocumentURI, pos types.Position) (*types.Hover, error) {
+	f, ok := h.getFile(uri)
+	if !ok {
+		return nil, fmt.Errorf("document not found: %v", uri)
+	}
+
+	sections := make([]string, 0)
+	var hoverRange *types.Range
+	explained := make(map[string]bool)
+	for _, config := range h.documentConfigs(f) {
+		if config.LintCommand == "" {
+			continue
+		}
+		for _, d := range h.diagnostics.forTool(uri, lintToolKey(config)) {
+			if !diagnosticCovers(d, pos) {
+				continue
+			}
+			if hoverRange == nil {
+				hoverRange = &d.Range
+			}
+
+			section := describeDiagnostic(d)
+			if config.ExplainCommand != "" && d.Code != "" {
+				rootPath := h.findRootPath(f.NormalizedFilename, config)
+				cmdStr := explainCommandString(config.ExplainCommand, d.Code, f.NormalizedFilename, rootPath)
+				if !explained[cmdStr] {
+					explained[cmdStr] = true
+					explanation, err := explainDiagnostic(ctx, cmdStr, rootPath, f, config)
+					if err != nil {
+						logs.Log.Logln(logs.Error, err.Error())
+					} else if explanation != "" {
+						section += "\n\n" + explanation
+					}
+				}
+			}
+			sections = append(sections, section)
+		}
+	}
+
+	if len(sections) == 0 {
+		return nil, nil
+	}
+
+	return &types.Hover{
+		Contents: types.MarkupContent{
+			Kind:  types.Markdown,
+			Value: strings.Join(sections, "\n\n---\n\n"),
+		},
+		Range: hoverRange,
+	}, nil
+}
+
+// diagnosticCovers reports whether the position is within the diagnostic,
+// diagnostics without a width cover their whole line like linters that do not report columns intend.
+func diagnosticCovers(d types.Diagnostic, pos types.Position) bool {
+	if d.Range.Start == d.Range.End {
+		return pos.Line == d.Range.Start.Line
+	}
+	return !positionBefore(pos, d.Range.Start) && !positionBefore(d.Range.End, pos)
+}
+
+func describeDiagnostic(d types.Diagnostic) string {
+	var b strings.Builder
+	if d.Source != nil {
+		fmt.Fprintf(&b, "**%s**", *d.Source)
+	}
+	if d.Code != "" {
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "`%s`", d.Code)
+	}
+	if b.Len() > 0 {
+		b.WriteString(": ")
+	}
+	b.WriteString(d.Message)
+	return b.String()
+}
+
+func explainCommandString(command string, code types.DiagnosticCode, fname, rootPath string) string {
+	command = strings.ReplaceAll(command, codePlaceholder, string(code))
+	return replaceMagicStrings(command, fname, rootPath)
+}
+
+// explainDiagnostic runs the explain command with the document on stdin.
+func explainDiagnostic(ctx context.Context, cmdStr, rootPath string, f fileRef, config types.Language) (string, error) {
+	cmd := buildExecCmd(ctx, cmdStr, rootPath, f.Text, config, true)
+	out, err := runFormattingCommand(cmd)
+
+	logs.Log.Logln(logs.Info, cmdStr)
+	logs.Log.Logln(logs.Debug, out)
+
+	if err != nil {
+		return "", fmt.Errorf("explain error: %s", err)
+	}
+
+	return strings.TrimSpace(strings.ReplaceAll(out, carriageReturn, "")), nil
+}
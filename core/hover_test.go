@@ -0,0 +1,87 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses echo with single quotes")
+	}
+	base, _ := os.Getwd()
+	file := filepath.Join(base, "foo")
+	uri := ParseLocalFileToURI(file)
+
+	h := &LangHandler{
+		RootPath: base,
+		configs: map[string][]types.Language{
+			"vim": {
+				{
+					LintCommand:        `echo ` + file + `:2:1:42:bad word`,
+					LintFormats:        []string{"%f:%l:%c:%n:%m"},
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
+					LintSource:         "vint",
+					ExplainCommand:     `echo 'rule ${CODE}'`,
+				},
+				{
+					LintCommand:        `echo ` + file + `:2:0:whole line`,
+					LintFormats:        []string{"%f:%l:%c:%m"},
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
+				},
+			},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "vim",
+				Text:               "ok\nbad word\n",
+				NormalizedFilename: file,
+				Uri:                uri,
+			},
+		},
+	}
+	_, err := h.getAllDiagnosticsForUri(t, uri)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		pos      types.Position
+		expected *types.Hover
+	}{
+		{
+			"on both diagnostics",
+			types.Position{Line: 1, Character: 2},
+			&types.Hover{
+				Contents: types.MarkupContent{
+					Kind:  types.Markdown,
+					Value: "**vint** `42`: bad word\n\nrule 42\n\n---\n\nwhole line",
+				},
+				Range: &types.Range{Start: types.Position{Line: 1, Character: 0}, End: types.Position{Line: 1, Character: 3}},
+			},
+		},
+		{
+			"only the whole line diagnostic",
+			types.Position{Line: 1, Character: 6},
+			&types.Hover{
+				Contents: types.MarkupContent{Kind: types.Markdown, Value: "whole line"},
+				Range:    &types.Range{Start: types.Position{Line: 1, Character: 0}, End: types.Position{Line: 1, Character: 0}},
+			},
+		},
+		{"no diagnostics", types.Position{Line: 0, Character: 0}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hover, err := h.Hover(t.Context(), uri, tt.pos)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, hover)
+		})
+	}
+}
@@ -3,11 +3,14 @@ package core
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/konradmalik/flint-ls/logs"
 	"github.com/konradmalik/flint-ls/types"
@@ -17,66 +20,149 @@ import (
 var unknownExitCode = -999
 var defaultLintFormats = []string{"%f:%l:%m", "%f:%l:%c:%m"}
 
+// ErrDocumentChanged is returned when lint results were discarded because the document
+// changed while linters were running. The caller should lint the document again.
+var ErrDocumentChanged = errors.New("document changed during linting")
+
 func (h *LangHandler) RunAllLinters(
 	ctx context.Context, uri types.DocumentURI, eventType types.EventType, diagnosticsOut chan<- types.PublishDiagnosticsParams, errorsOut chan<- error) error {
-	f, ok := h.files[uri]
+	f, ok := h.getFile(uri)
 	if !ok {
 		return fmt.Errorf("document not found: %v", uri)
 	}
 
-	configs := getLintConfigsForDocument(f.NormalizedFilename, f.LanguageID, h.configs, eventType)
+	allConfigs := h.configsFor(f.NormalizedFilename)
+	configs := getLintConfigsForDocument(f.NormalizedFilename, f.LanguageID, allConfigs, eventType)
 	if len(configs) == 0 {
 		logs.Log.Logf(logs.Debug, "no matching lint configs for LanguageID: %v", f.LanguageID)
 		return nil
 	}
 
-	// to reset existing
-	diagnosticsOut <- types.PublishDiagnosticsParams{
-		URI:         uri,
-		Diagnostics: make([]types.Diagnostic, 0),
-		Version:     f.Version,
+	// drop results of tools no longer configured for this document, results of the tools about to run
+	// are replaced as each of them finishes
+	h.diagnostics.mu.Lock()
+	if diagnostics, changed := h.diagnostics.retain(uri, configuredLintTools(f.LanguageID, allConfigs)); changed {
+		diagnosticsOut <- types.PublishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: diagnostics,
+			Version:     f.Version,
+		}
 	}
+	h.diagnostics.mu.Unlock()
 
+	var stale atomic.Bool
 	var wg sync.WaitGroup
 	for _, config := range configs {
 		wg.Go(func() {
 			rootPath := h.findRootPath(f.NormalizedFilename, config)
-			diagnostics, err := lintDocument(ctx, rootPath, *f, config)
+			if boolOrDefault(config.LintWorkspace, false) {
+				// workspace linters read files from disk, so their results do not depend on the document version
+				if _, err := h.runWorkspaceLinter(ctx, workspaceLint{rootPath: rootPath, config: config}, diagnosticsOut); err != nil {
+					logs.Log.Logln(logs.Error, err.Error())
+					errorsOut <- err
+				}
+				return
+			}
+
+			diagnostics, err := lintDocument(ctx, rootPath, f, config, h.positionEncoding)
 			if err != nil {
 				logs.Log.Logln(logs.Error, err.Error())
 				errorsOut <- err
 				return
 			}
+			if ctx.Err() != nil {
+				// run was superseded or cancelled, its results must not replace the current ones
+				return
+			}
 
+			// publish while holding the lock so that merged results are sent in the order they were stored
+			h.diagnostics.mu.Lock()
+			defer h.diagnostics.mu.Unlock()
+			if !h.isCurrentVersion(uri, f.Version) {
+				// results point at text the user no longer has
+				logs.Log.Logf(logs.Debug, "discarding stale lint results for %v version %d", uri, f.Version)
+				stale.Store(true)
+				return
+			}
 			diagnosticsOut <- types.PublishDiagnosticsParams{
 				URI:         uri,
-				Diagnostics: diagnostics,
+				Diagnostics: h.diagnostics.set(uri, lintToolKey(config), diagnostics),
 				Version:     f.Version,
 			}
 		})
 	}
 
 	wg.Wait()
+	if stale.Load() {
+		return fmt.Errorf("%w: %v", ErrDocumentChanged, uri)
+	}
 	return nil
 }
 
-func lintDocument(ctx context.Context, rootPath string, f fileRef, config types.Language) ([]types.Diagnostic, error) {
-	diagnostics := make([]types.Diagnostic, 0)
+// PullDiagnostics answers a textDocument/diagnostic request.
+// Linters are only run when the document or the configured tools changed since the last report,
+// or when the event enables linters that may not have run yet.
+func (h *LangHandler) PullDiagnostics(
+	ctx context.Context, uri types.DocumentURI, eventType types.EventType, previousResultID string, errorsOut chan<- error) (types.DocumentDiagnosticReport, error) {
+	f, ok := h.getFile(uri)
+	if !ok {
+		return types.DocumentDiagnosticReport{}, fmt.Errorf("document not found: %v", uri)
+	}
+
+	allConfigs := h.configsFor(f.NormalizedFilename)
+	resultID := diagnosticsResultID(f.Version, configuredLintTools(f.LanguageID, allConfigs))
+	if eventType != types.EventTypeChange || h.diagnostics.resultID(uri) != resultID {
+		diagnosticsOut := make(chan types.PublishDiagnosticsParams)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			// merged results are read from the store once all linters finish
+			for range diagnosticsOut {
+			}
+		}()
+
+		err := h.RunAllLinters(ctx, uri, eventType, diagnosticsOut, errorsOut)
+		close(diagnosticsOut)
+		<-done
+		if err != nil {
+			return types.DocumentDiagnosticReport{}, err
+		}
+		if err := ctx.Err(); err != nil {
+			return types.DocumentDiagnosticReport{}, err
+		}
+		h.diagnostics.setResultID(uri, resultID)
+	} else if previousResultID == resultID {
+		return types.DocumentDiagnosticReport{Kind: types.DiagnosticReportUnchanged, ResultID: resultID}, nil
+	}
+
+	items := h.diagnostics.current(uri)
+	return types.DocumentDiagnosticReport{Kind: types.DiagnosticReportFull, ResultID: resultID, Items: &items}, nil
+}
+
+func lintDocument(ctx context.Context, rootPath string, f fileRef, config types.Language, encoding types.PositionEncodingKind) ([]types.Diagnostic, error) {
 	cmdStr := buildLintCommandString(rootPath, f, config)
-	cmd := buildExecCmd(ctx, cmdStr, rootPath, f.Text, config, config.LintStdin)
+	cmd := buildExecCmd(ctx, cmdStr, rootPath, f.Text, config, boolOrDefault(config.LintStdin, false))
 
+	done := traceTool(ctx, config, config.LintCommand, f.NormalizedFilename)
 	lintOutput, err := runLintCommand(cmd, &config)
+	done(err)
 	logs.Log.Logln(logs.Info, cmdStr)
 	logs.Log.Logln(logs.Debug, string(lintOutput))
 	if err != nil {
 		return nil, err
 	}
 
+	return parseLintOutput(rootPath, f, config, lintOutput, encoding)
+}
+
+// parseLintOutput turns the output of a linter into diagnostics of the document, entries for other files are skipped.
+func parseLintOutput(rootPath string, f fileRef, config types.Language, lintOutput []byte, encoding types.PositionEncodingKind) ([]types.Diagnostic, error) {
 	efms, err := buildErrorformats(config.LintFormats)
 	if err != nil {
 		return nil, err
 	}
 
+	diagnostics := make([]types.Diagnostic, 0)
 	efmsScanner := efms.NewScanner(bytes.NewReader(lintOutput))
 	for efmsScanner.Scan() {
 		entry := efmsScanner.Entry()
@@ -90,7 +176,7 @@ func lintDocument(ctx context.Context, rootPath string, f fileRef, config types.
 			continue
 		}
 
-		diagnostic := parseEfmEntryToDiagnostic(entry, config, f)
+		diagnostic := parseEfmEntryToDiagnostic(entry, config, f, encoding)
 		diagnostics = append(diagnostics, diagnostic)
 	}
 
@@ -99,8 +185,9 @@ func lintDocument(ctx context.Context, rootPath string, f fileRef, config types.
 
 func getSeverity(typ rune, categoryMap map[string]string, defaultSeverity types.DiagnosticSeverity) types.DiagnosticSeverity {
 	// we allow the config to provide a mapping between LSP types E,W,I,N and whatever categories the linter has
-	if len(categoryMap) > 0 {
-		typ = []rune(categoryMap[string(typ)])[0]
+	// types missing from the mapping are kept
+	if mapped := categoryMap[string(typ)]; mapped != "" {
+		typ = []rune(mapped)[0]
 	}
 
 	severity := types.DiagError
@@ -128,7 +215,7 @@ func getLintConfigsForDocument(fname, langId string, allConfigs map[string][]typ
 			continue
 		}
 		// if we require markers and find that they dont exist we do not add the configuration
-		if dir := matchRootPath(fname, cfg.RootMarkers); dir == "" && cfg.RequireMarker {
+		if dir := matchRootPath(fname, cfg.RootMarkers); dir == "" && boolOrDefault(cfg.RequireMarker, false) {
 			continue
 		}
 		switch eventType {
@@ -151,21 +238,47 @@ func getLintConfigsForDocument(fname, langId string, allConfigs map[string][]typ
 	return configs
 }
 
+// configuredLintTools returns keys of all linters configured for the language, regardless of the event.
+func configuredLintTools(langId string, allConfigs map[string][]types.Language) []string {
+	keys := make([]string, 0)
+	for _, cfg := range getAllConfigsForLang(allConfigs, langId) {
+		if cfg.LintCommand != "" {
+			keys = append(keys, lintToolKey(cfg))
+		}
+	}
+	return keys
+}
+
+// compiledErrorformats caches compiled error formats by their source, so that each is compiled once.
+var compiledErrorformats sync.Map
+
+type compiledErrorformat struct {
+	efms *errorformat.Errorformat
+	err  error
+}
+
 func buildErrorformats(configFormats []string) (*errorformat.Errorformat, error) {
 	if len(configFormats) == 0 {
 		configFormats = defaultLintFormats
 	}
 
+	key := strings.Join(configFormats, "\n")
+	if v, ok := compiledErrorformats.Load(key); ok {
+		compiled := v.(compiledErrorformat)
+		return compiled.efms, compiled.err
+	}
+
 	efms, err := errorformat.NewErrorformat(configFormats)
 	if err != nil {
-		return nil, fmt.Errorf("invalid error-format: %v", configFormats)
+		err = fmt.Errorf("invalid error-format: %v: %v", configFormats, err)
 	}
-	return efms, nil
+	compiledErrorformats.Store(key, compiledErrorformat{efms: efms, err: err})
+	return efms, err
 }
 
 func buildLintCommandString(rootPath string, f fileRef, config types.Language) string {
 	command := config.LintCommand
-	if !config.LintStdin && !strings.Contains(command, inputPlaceholder) {
+	if !boolOrDefault(config.LintStdin, false) && !strings.Contains(command, inputPlaceholder) {
 		command = command + " " + inputPlaceholder
 	}
 	return replaceMagicStrings(command, f.NormalizedFilename, rootPath)
@@ -177,7 +290,7 @@ func runLintCommand(cmd *exec.Cmd, config *types.Language) ([]byte, error) {
 	isExitCode0 := lintCmdError == nil
 	if isExitCode0 {
 		// LintIgnoreExitCode means despite lint returning 0, we still parse for errors
-		if config.LintIgnoreExitCode {
+		if boolOrDefault(config.LintIgnoreExitCode, false) {
 			return lintOutput, nil
 		}
 		return nil, nil
@@ -205,7 +318,7 @@ func parseErrorExitCode(err error) int {
 }
 
 func replaceStdinInEntryFilename(entryFilename string, config *types.Language, fname string) string {
-	if config.LintStdin && isStdinPlaceholder(entryFilename) {
+	if boolOrDefault(config.LintStdin, false) && isStdinPlaceholder(entryFilename) {
 		entryFilename = fname
 	}
 	return filepath.ToSlash(entryFilename)
@@ -226,13 +339,13 @@ func isEntryForRequestedURI(rootPath string, uri types.DocumentURI, entry *error
 	return comparePaths(string(diagURI), string(uri))
 }
 
-func parseEfmEntryToDiagnostic(entry *errorformat.Entry, config types.Language, f fileRef) types.Diagnostic {
+func parseEfmEntryToDiagnostic(entry *errorformat.Entry, config types.Language, f fileRef, encoding types.PositionEncodingKind) types.Diagnostic {
 	// vast majority of linters report 1-based lines and columns, but lsp requires 0-based
 	// BUG: LintOffset should be added, not subtracted. But to keep backwards compatibility let's leave this bug here
-	lineStart := max(entry.Lnum-1-config.LintOffset, 0)
+	lineStart := max(entry.Lnum-1-intOrDefault(config.LintOffset, 0), 0)
 	lineEnd := lineStart
 	if entry.EndLnum != 0 {
-		lineEnd = max(entry.EndLnum-1-config.LintOffset, 0)
+		lineEnd = max(entry.EndLnum-1-intOrDefault(config.LintOffset, 0), 0)
 	}
 
 	colStart := max(entry.Col-1, 0)
@@ -242,14 +355,16 @@ func parseEfmEntryToDiagnostic(entry *errorformat.Entry, config types.Language,
 	// if the linter reports 0 it means the whole line
 	if entry.Col != 0 {
 		// We only add the offset if the linter reports entry.Col > 0 because 0 means the whole line
-		colStart = colStart + config.LintOffsetColumns
+		colStart = colStart + intOrDefault(config.LintOffsetColumns, 0)
+		colStart = convertColumn(lineAt(f.Text, lineStart), colStart, config.LintColumnUnit, config.LintTabWidth, encoding)
 
 		if entry.EndCol != 0 {
 			colEnd = max(entry.EndCol-1, 0)
-			colEnd = colEnd + config.LintOffsetColumns
+			colEnd = colEnd + intOrDefault(config.LintOffsetColumns, 0)
+			colEnd = convertColumn(lineAt(f.Text, lineEnd), colEnd, config.LintColumnUnit, config.LintTabWidth, encoding)
 		} else {
-			word := WordAtUtf16(f.Text, types.Position{Line: lineStart, Character: colStart})
-			colEnd = colStart + len(word)
+			word := WordAt(f.Text, types.Position{Line: lineStart, Character: colStart}, encoding)
+			colEnd = colStart + encodedLen(word, encoding)
 		}
 	}
 
@@ -258,13 +373,59 @@ func parseEfmEntryToDiagnostic(entry *errorformat.Entry, config types.Language,
 			Start: types.Position{Line: lineStart, Character: colStart},
 			End:   types.Position{Line: lineEnd, Character: colEnd},
 		},
-		Code:     itoaPtrIfNotZero(entry.Nr),
+		Code:     diagnosticCode(entry),
 		Message:  getLintMessagePrefix(config) + entry.Text,
 		Severity: getSeverity(entry.Type, config.LintCategoryMap, config.LintSeverity),
 		Source:   getLintSource(config),
 	}
 }
 
+// diagnosticCode returns the code captured by %n as the tool prints it, with the letters and zeros before
+// the number, like SC2086 or W0612. Codes printed without letters are kept as numbers.
+func diagnosticCode(entry *errorformat.Entry) types.DiagnosticCode {
+	if entry.Nr == 0 {
+		return ""
+	}
+	nr := strconv.Itoa(entry.Nr)
+	for _, line := range entry.Lines {
+		for i := strings.Index(line, nr); i >= 0; i = nextIndex(line, nr, i) {
+			end := i + len(nr)
+			if end < len(line) && isAlphanumeric(line[end]) {
+				continue
+			}
+			digits := i
+			for digits > 0 && line[digits-1] == '0' {
+				digits--
+			}
+			start := digits
+			for start > 0 && isLetter(line[start-1]) {
+				start--
+			}
+			if start < digits && (start == 0 || !isAlphanumeric(line[start-1])) {
+				return types.DiagnosticCode(line[start:end])
+			}
+		}
+	}
+	return types.DiagnosticCode(nr)
+}
+
+// nextIndex returns the index of the next occurrence of substr in s after the one at i, or -1.
+func nextIndex(s, substr string, i int) int {
+	j := strings.Index(s[i+1:], substr)
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isAlphanumeric(c byte) bool {
+	return isLetter(c) || ('0' <= c && c <= '9')
+}
+
 func getLintSource(config types.Language) *string {
 	if config.LintSource != "" {
 		return &config.LintSource
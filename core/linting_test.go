@@ -1,13 +1,14 @@
 package core
 
 import (
-	"fmt"
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/konradmalik/flint-ls/types"
 	"github.com/reviewdog/errorformat"
@@ -61,8 +62,8 @@ func TestLinting(t *testing.T) {
 			name: "NoFileMatched",
 			langConfig: types.Language{
 				LintCommand:        `echo nofile:2:No it is normal!`,
-				LintIgnoreExitCode: true,
-				LintStdin:          true,
+				LintIgnoreExitCode: boolPtr(true),
+				LintStdin:          boolPtr(true),
 			},
 			expectDiagnostics: 0,
 		},
@@ -70,8 +71,8 @@ func TestLinting(t *testing.T) {
 			name: "FileMatched",
 			langConfig: types.Language{
 				LintCommand:        `echo ` + file + `:2:No it is normal!`,
-				LintIgnoreExitCode: true,
-				LintStdin:          true,
+				LintIgnoreExitCode: boolPtr(true),
+				LintStdin:          boolPtr(true),
 			},
 			expectDiagnostics: 1,
 			verify: func(t *testing.T, d []types.Diagnostic) {
@@ -85,8 +86,8 @@ func TestLinting(t *testing.T) {
 			name: "NoIgnoreExitCodeIsRespected",
 			langConfig: types.Language{
 				LintCommand:        `echo ` + file + `:2:No it is normal!`,
-				LintIgnoreExitCode: false,
-				LintStdin:          true,
+				LintIgnoreExitCode: boolPtr(false),
+				LintStdin:          boolPtr(true),
 			},
 			expectDiagnostics: 0,
 		},
@@ -94,8 +95,8 @@ func TestLinting(t *testing.T) {
 			name: "CancelledErrorCodeIsIgnored",
 			langConfig: types.Language{
 				LintCommand:        `exit -1`,
-				LintIgnoreExitCode: true,
-				LintStdin:          true,
+				LintIgnoreExitCode: boolPtr(true),
+				LintStdin:          boolPtr(true),
 			},
 			expectDiagnostics: 0,
 		},
@@ -124,7 +125,7 @@ func TestLinting(t *testing.T) {
 	}
 }
 
-func TestDiagnosticsResetOnEachRun(t *testing.T) {
+func TestDiagnosticsKeptUntilLinterFinishes(t *testing.T) {
 	base, _ := os.Getwd()
 	file := filepath.Join(base, "foo")
 	uri := ParseLocalFileToURI(file)
@@ -135,8 +136,8 @@ func TestDiagnosticsResetOnEachRun(t *testing.T) {
 			"vim": {
 				{
 					LintCommand:        `echo ` + file + `:2:No it is normal!`,
-					LintIgnoreExitCode: true,
-					LintStdin:          true,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
 				},
 			},
 		},
@@ -152,10 +153,208 @@ func TestDiagnosticsResetOnEachRun(t *testing.T) {
 
 	pd, err := h.getAllPublishDiagnosticsParamsForUriWithEvent(t, uri, types.EventTypeSave)
 	assert.NoError(t, err)
+	assert.Len(t, pd, 1)
+	assert.Len(t, pd[0].Diagnostics, 1)
 
+	// previous results are not cleared before the linter runs again
+	pd, err = h.getAllPublishDiagnosticsParamsForUriWithEvent(t, uri, types.EventTypeSave)
+	assert.NoError(t, err)
+	assert.Len(t, pd, 1)
+	assert.Len(t, pd[0].Diagnostics, 1)
+}
+
+func TestDiagnosticsMergedAcrossLinters(t *testing.T) {
+	base, _ := os.Getwd()
+	file := filepath.Join(base, "foo")
+	uri := ParseLocalFileToURI(file)
+
+	first := types.Language{
+		LintCommand:        `echo ` + file + `:1:first`,
+		LintIgnoreExitCode: boolPtr(true),
+		LintStdin:          boolPtr(true),
+	}
+	second := types.Language{
+		LintCommand:        `echo ` + file + `:2:second`,
+		LintIgnoreExitCode: boolPtr(true),
+		LintStdin:          boolPtr(true),
+		LintOnChange:       boolPtr(false),
+	}
+
+	h := &LangHandler{
+		RootPath: base,
+		configs: map[string][]types.Language{
+			"vim": {first, second},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "vim",
+				Text:               "scriptencoding utf-8\nabnormal!\n",
+				NormalizedFilename: file,
+				Uri:                uri,
+			},
+		},
+	}
+
+	pd, err := h.getAllPublishDiagnosticsParamsForUriWithEvent(t, uri, types.EventTypeSave)
+	assert.NoError(t, err)
 	assert.Len(t, pd, 2)
-	assert.Empty(t, pd[0].Diagnostics)
-	assert.NotEmpty(t, pd[1].Diagnostics)
+	assert.Len(t, pd[1].Diagnostics, 2)
+
+	// only the first linter runs on change, results of the second one are kept
+	pd, err = h.getAllPublishDiagnosticsParamsForUriWithEvent(t, uri, types.EventTypeChange)
+	assert.NoError(t, err)
+	assert.Len(t, pd, 1)
+	assert.Len(t, pd[0].Diagnostics, 2)
+
+	// removed linters lose their results before the remaining ones run
+	h.configs["vim"] = []types.Language{first}
+	pd, err = h.getAllPublishDiagnosticsParamsForUriWithEvent(t, uri, types.EventTypeChange)
+	assert.NoError(t, err)
+	assert.Len(t, pd, 2)
+	assert.Len(t, pd[0].Diagnostics, 1)
+	assert.Equal(t, "first", pd[0].Diagnostics[0].Message)
+	assert.Len(t, pd[1].Diagnostics, 1)
+}
+
+func TestDiagnosticsOfLintersWithTheSameCommand(t *testing.T) {
+	base, _ := os.Getwd()
+	file := filepath.Join(base, "foo")
+	uri := ParseLocalFileToURI(file)
+
+	lint := types.Language{
+		LintCommand:        `echo ` + file + `:1:found`,
+		LintIgnoreExitCode: boolPtr(true),
+		LintStdin:          boolPtr(true),
+	}
+	strict := lint
+	strict.LintSource = "strict"
+
+	h := &LangHandler{
+		RootPath: base,
+		configs: map[string][]types.Language{
+			"vim": {lint, strict},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "vim",
+				Text:               "scriptencoding utf-8\n",
+				NormalizedFilename: file,
+				Uri:                uri,
+			},
+		},
+	}
+
+	pd, err := h.getAllPublishDiagnosticsParamsForUriWithEvent(t, uri, types.EventTypeSave)
+	assert.NoError(t, err)
+	assert.Len(t, pd, 2)
+	assert.Len(t, pd[1].Diagnostics, 2)
+}
+
+func TestLintStaleResultsDiscarded(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh specific sleep")
+	}
+	base, _ := os.Getwd()
+	file := filepath.Join(base, "foo")
+	uri := ParseLocalFileToURI(file)
+
+	h := &LangHandler{
+		RootPath: base,
+		configs: map[string][]types.Language{
+			"vim": {
+				{
+					LintCommand:        `sleep 0.2; echo ` + file + `:2:No it is normal!`,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
+				},
+			},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "vim",
+				Text:               "scriptencoding utf-8\nabnormal!\n",
+				NormalizedFilename: file,
+				Uri:                uri,
+				Version:            1,
+			},
+		},
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		version := 2
+		assert.NoError(t, h.UpdateFile(uri, "scriptencoding utf-8\n", &version))
+	}()
+
+	pd, err := h.getAllPublishDiagnosticsParamsForUriWithEvent(t, uri, types.EventTypeChange)
+	assert.ErrorIs(t, err, ErrDocumentChanged)
+	assert.Empty(t, pd)
+}
+
+func TestPullDiagnostics(t *testing.T) {
+	base, _ := os.Getwd()
+	file := filepath.Join(base, "foo")
+	uri := ParseLocalFileToURI(file)
+	counter := filepath.Join(t.TempDir(), "runs")
+
+	h := &LangHandler{
+		RootPath: base,
+		configs: map[string][]types.Language{
+			"vim": {
+				{
+					LintCommand:        `echo run >> ` + counter + ` && echo ` + file + `:2:No it is normal!`,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
+				},
+			},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "vim",
+				Text:               "scriptencoding utf-8\nabnormal!\n",
+				NormalizedFilename: file,
+				Uri:                uri,
+				Version:            1,
+			},
+		},
+	}
+	runs := func() int {
+		b, _ := os.ReadFile(counter)
+		return strings.Count(string(b), "run")
+	}
+
+	report, err := h.PullDiagnostics(t.Context(), uri, types.EventTypeOpen, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.DiagnosticReportFull, report.Kind)
+	assert.Len(t, *report.Items, 1)
+	assert.Equal(t, 1, runs())
+
+	// same version and tools, results are reused
+	unchanged, err := h.PullDiagnostics(t.Context(), uri, types.EventTypeChange, report.ResultID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.DiagnosticReportUnchanged, unchanged.Kind)
+	assert.Equal(t, report.ResultID, unchanged.ResultID)
+	assert.Nil(t, unchanged.Items)
+
+	full, err := h.PullDiagnostics(t.Context(), uri, types.EventTypeChange, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.DiagnosticReportFull, full.Kind)
+	assert.Len(t, *full.Items, 1)
+	assert.Equal(t, 1, runs())
+
+	// new version, linters run again
+	version := 2
+	assert.NoError(t, h.UpdateFile(uri, "scriptencoding utf-8\n", &version))
+	changed, err := h.PullDiagnostics(t.Context(), uri, types.EventTypeChange, report.ResultID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, types.DiagnosticReportFull, changed.Kind)
+	assert.NotEqual(t, report.ResultID, changed.ResultID)
+	assert.Equal(t, 2, runs())
+
+	// saving may enable other linters, so they run even if nothing changed
+	_, err = h.PullDiagnostics(t.Context(), uri, types.EventTypeSave, changed.ResultID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, runs())
 }
 
 func TestLintFileMatchedWildcard(t *testing.T) {
@@ -169,8 +368,8 @@ func TestLintFileMatchedWildcard(t *testing.T) {
 			types.Wildcard: {
 				{
 					LintCommand:        `echo ` + file + `:2:No it is normal!`,
-					LintIgnoreExitCode: true,
-					LintStdin:          true,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
 				},
 			},
 		},
@@ -208,9 +407,9 @@ func TestLintOffsetColumnsZero(t *testing.T) {
 				{
 					LintCommand:        `echo ` + file + `:2:0:msg`,
 					LintFormats:        []string{"%f:%l:%c:%m"},
-					LintIgnoreExitCode: true,
-					LintStdin:          true,
-					LintOffsetColumns:  1,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
+					LintOffsetColumns:  intPtr(1),
 				},
 			},
 		},
@@ -245,8 +444,8 @@ func TestLintOffsetColumnsNoOffset(t *testing.T) {
 				{
 					LintCommand:        `echo ` + file + `:2:1:msg`,
 					LintFormats:        []string{"%f:%l:%c:%m"},
-					LintIgnoreExitCode: true,
-					LintStdin:          true,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
 				},
 			},
 		},
@@ -281,9 +480,9 @@ func TestLintOffsetColumnsNonZero(t *testing.T) {
 				{
 					LintCommand:        `echo ` + file + `:2:1:msg`,
 					LintFormats:        []string{"%f:%l:%c:%m"},
-					LintIgnoreExitCode: true,
-					LintStdin:          true,
-					LintOffsetColumns:  1,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
+					LintOffsetColumns:  intPtr(1),
 				},
 			},
 		},
@@ -320,8 +519,8 @@ func TestLintCategoryMap(t *testing.T) {
 			types.Wildcard: {
 				{
 					LintCommand:        `echo ` + file + `:2:1:R:No it is normal!`,
-					LintIgnoreExitCode: true,
-					LintStdin:          true,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
 					LintFormats:        formats,
 					LintCategoryMap:    mapping,
 				},
@@ -356,9 +555,9 @@ func TestLintRequireRootMarker(t *testing.T) {
 			"vim": {
 				{
 					LintCommand:        `echo ` + file + `:2:No it is normal!`,
-					LintIgnoreExitCode: true,
-					LintStdin:          true,
-					RequireMarker:      true,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
+					RequireMarker:      boolPtr(true),
 					RootMarkers:        []string{".vimlintrc"},
 				},
 			},
@@ -393,7 +592,7 @@ func TestLintSingleEntry(t *testing.T) {
 				{
 					LintCommand:        `echo ` + file + `:2:1:First file! && echo ` + file2 + `:1:2:Second file!`,
 					LintFormats:        []string{"%f:%l:%c:%m"},
-					LintIgnoreExitCode: true,
+					LintIgnoreExitCode: boolPtr(true),
 				},
 			},
 		},
@@ -435,7 +634,7 @@ func TestLintMultipleEntries(t *testing.T) {
 				{
 					LintCommand:        `echo ` + file + `:2:1:First file! && echo ` + file2 + `:2:3:Second file! && echo ` + file2 + `:Empty l and c!`,
 					LintFormats:        []string{"%f:%l:%c:%m", "%f:%m"},
-					LintIgnoreExitCode: true,
+					LintIgnoreExitCode: boolPtr(true),
 				},
 			},
 		},
@@ -476,8 +675,8 @@ func TestLintNoDiagnostics(t *testing.T) {
 			"vim": {
 				{
 					LintCommand:        "echo ",
-					LintIgnoreExitCode: true,
-					LintStdin:          true,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
 				},
 			},
 		},
@@ -508,8 +707,8 @@ func TestLintEventTypes(t *testing.T) {
 			"vim": {
 				{
 					LintCommand:        `echo ` + file + `:2:No it is normal!`,
-					LintIgnoreExitCode: true,
-					LintStdin:          true,
+					LintIgnoreExitCode: boolPtr(true),
+					LintStdin:          boolPtr(true),
 				},
 			},
 		},
@@ -581,6 +780,29 @@ func TestLintEventTypes(t *testing.T) {
 	}
 }
 
+func TestDiagnosticCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		nr       int
+		line     string
+		expected types.DiagnosticCode
+	}{
+		{"none", 0, "stdin:1:1: whatever", ""},
+		{"prefix", 401, "stdin:1:1: F401 'os' imported but unused", "F401"},
+		{"bracketed", 2086, "-:3:6: note: Double quote to prevent globbing. [SC2086]", "SC2086"},
+		{"zeros", 612, "main.py:3:4: W0612: Unused variable 'x' (unused-variable)", "W0612"},
+		{"line with the same number", 401, "stdin:401:1: F401 'os' imported but unused", "F401"},
+		{"number only", 42, "foo:2:1:42:bad word", "42"},
+		{"inside another number", 501, "foo:1:1: E15012 too long", "501"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &errorformat.Entry{Nr: tt.nr, Lines: []string{tt.line}}
+			assert.Equal(t, tt.expected, diagnosticCode(entry))
+		})
+	}
+}
+
 func TestGetSeverity(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -595,6 +817,7 @@ func TestGetSeverity(t *testing.T) {
 		{"Hint type", 'N', nil, 0, types.DiagHint},
 		{"Default severity overrides", 'X', nil, types.DiagWarning, types.DiagWarning},
 		{"Category map remap", 'X', map[string]string{"X": "W"}, 0, types.DiagWarning},
+		{"Category map without the type", 'W', map[string]string{"X": "E"}, 0, types.DiagWarning},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -684,8 +907,8 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 				Type: 'E',
 			},
 			cfg: &types.Language{
-				LintOffset:        0,
-				LintOffsetColumns: 0,
+				LintOffset:        intPtr(0),
+				LintOffsetColumns: intPtr(0),
 			},
 			expected: types.Diagnostic{
 				Message:  "world bad",
@@ -705,8 +928,8 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 				Type: 'E',
 			},
 			cfg: &types.Language{
-				LintOffset:        0,
-				LintOffsetColumns: 0,
+				LintOffset:        intPtr(0),
+				LintOffsetColumns: intPtr(0),
 			},
 			expected: types.Diagnostic{
 				Message:  "world bad",
@@ -726,8 +949,8 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 				Type: 'E',
 			},
 			cfg: &types.Language{
-				LintOffset:        0,
-				LintOffsetColumns: 0,
+				LintOffset:        intPtr(0),
+				LintOffsetColumns: intPtr(0),
 			},
 			expected: types.Diagnostic{
 				Message:  "golang bad",
@@ -747,8 +970,8 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 				Type: 'E',
 			},
 			cfg: &types.Language{
-				LintOffset:        0,
-				LintOffsetColumns: 0,
+				LintOffset:        intPtr(0),
+				LintOffsetColumns: intPtr(0),
 			},
 			expected: types.Diagnostic{
 				Message:  "golang not rulezz",
@@ -768,8 +991,8 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 				Type: 'E',
 			},
 			cfg: &types.Language{
-				LintOffset:        -1,
-				LintOffsetColumns: 0,
+				LintOffset:        intPtr(-1),
+				LintOffsetColumns: intPtr(0),
 			},
 			expected: types.Diagnostic{
 				Message:  "world bad",
@@ -789,8 +1012,8 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 				Type: 'E',
 			},
 			cfg: &types.Language{
-				LintOffset:        0,
-				LintOffsetColumns: 1,
+				LintOffset:        intPtr(0),
+				LintOffsetColumns: intPtr(1),
 			},
 			expected: types.Diagnostic{
 				Message:  "world bad",
@@ -810,8 +1033,8 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 				Type: 'E',
 			},
 			cfg: &types.Language{
-				LintOffset:        0,
-				LintOffsetColumns: 11,
+				LintOffset:        intPtr(0),
+				LintOffsetColumns: intPtr(11),
 			},
 			expected: types.Diagnostic{
 				Message:  "world bad",
@@ -832,8 +1055,8 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 				Type:    'E',
 			},
 			cfg: &types.Language{
-				LintOffset:        -2,
-				LintOffsetColumns: 0,
+				LintOffset:        intPtr(-2),
+				LintOffsetColumns: intPtr(0),
 			},
 			expected: types.Diagnostic{
 				Message:  "bad",
@@ -855,8 +1078,8 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 				Type:    'E',
 			},
 			cfg: &types.Language{
-				LintOffset:        0,
-				LintOffsetColumns: 2,
+				LintOffset:        intPtr(0),
+				LintOffsetColumns: intPtr(2),
 			},
 			expected: types.Diagnostic{
 				Message:  "bad",
@@ -871,7 +1094,7 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			diag := parseEfmEntryToDiagnostic(tt.entry, *tt.cfg, *file)
+			diag := parseEfmEntryToDiagnostic(tt.entry, *tt.cfg, *file, types.UTF16)
 			assert.Equal(t, tt.expected.Message, diag.Message)
 			assert.Equal(t, tt.expected.Severity, diag.Severity)
 			assert.Equal(t, tt.expected.Range.Start.Line, diag.Range.Start.Line)
@@ -882,6 +1105,60 @@ func TestParseEfmEntryToDiagnostic(t *testing.T) {
 	}
 }
 
+func TestParseEfmEntryToDiagnosticColumnUnits(t *testing.T) {
+	file := &fileRef{Text: "x := \"héllo\" + wörld\n\tbad\n", LanguageID: "txt"}
+	tests := []struct {
+		name     string
+		entry    *errorformat.Entry
+		cfg      types.Language
+		expected types.Range
+	}{
+		{
+			name:  "byte columns after non-ascii text",
+			entry: &errorformat.Entry{Lnum: 1, Col: 17, Text: "bad"},
+			cfg:   types.Language{LintColumnUnit: types.ColumnUnitByte},
+			expected: types.Range{
+				Start: types.Position{Line: 0, Character: 15},
+				End:   types.Position{Line: 0, Character: 20},
+			},
+		},
+		{
+			name:  "byte columns with end column",
+			entry: &errorformat.Entry{Lnum: 1, Col: 7, EndCol: 13, Text: "bad"},
+			cfg:   types.Language{LintColumnUnit: types.ColumnUnitByte},
+			expected: types.Range{
+				Start: types.Position{Line: 0, Character: 6},
+				End:   types.Position{Line: 0, Character: 11},
+			},
+		},
+		{
+			name:  "display columns after a tab",
+			entry: &errorformat.Entry{Lnum: 2, Col: 9, Text: "bad"},
+			cfg:   types.Language{LintColumnUnit: types.ColumnUnitDisplay},
+			expected: types.Range{
+				Start: types.Position{Line: 1, Character: 1},
+				End:   types.Position{Line: 1, Character: 4},
+			},
+		},
+		{
+			name:  "display columns with custom tab width",
+			entry: &errorformat.Entry{Lnum: 2, Col: 3, Text: "bad"},
+			cfg:   types.Language{LintColumnUnit: types.ColumnUnitDisplay, LintTabWidth: 2},
+			expected: types.Range{
+				Start: types.Position{Line: 1, Character: 1},
+				End:   types.Position{Line: 1, Character: 4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diag := parseEfmEntryToDiagnostic(tt.entry, tt.cfg, *file, types.UTF16)
+			assert.Equal(t, tt.expected, diag.Range)
+		})
+	}
+}
+
 func (h *LangHandler) getAllDiagnosticsForUri(t *testing.T, uri types.DocumentURI) ([]types.Diagnostic, error) {
 	return h.getAllDiagnosticsForUriWithEvent(t, uri, types.EventTypeChange)
 }
@@ -899,7 +1176,7 @@ func (h *LangHandler) getAllPublishDiagnosticsParamsForUriWithEvent(t *testing.T
 	var wg sync.WaitGroup
 
 	diagnosticsOut := make([]types.PublishDiagnosticsParams, 0)
-	errorsOut := make([]string, 0)
+	errorsOut := make([]error, 0)
 
 	func() {
 		diagnosticsChan := make(chan types.PublishDiagnosticsParams)
@@ -909,7 +1186,7 @@ func (h *LangHandler) getAllPublishDiagnosticsParamsForUriWithEvent(t *testing.T
 
 		wg.Go(func() {
 			for e := range errorsChan {
-				errorsOut = append(errorsOut, e.Error())
+				errorsOut = append(errorsOut, e)
 			}
 		})
 
@@ -921,13 +1198,10 @@ func (h *LangHandler) getAllPublishDiagnosticsParamsForUriWithEvent(t *testing.T
 
 		err := h.RunAllLinters(t.Context(), uri, event, diagnosticsChan, errorsChan)
 		if err != nil {
-			errorsOut = append(errorsOut, err.Error())
+			errorsOut = append(errorsOut, err)
 		}
 	}()
 
 	wg.Wait()
-	if len(errorsOut) != 0 {
-		return nil, fmt.Errorf("%s", strings.Join(errorsOut, ";"))
-	}
-	return diagnosticsOut, nil
+	return diagnosticsOut, errors.Join(errorsOut...)
 }
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// presetFiles are tool definitions for common linters and formatters, written like tools in a config file.
+// The name of a preset is its file name without the extension.
+//
+//go:embed presets/*.yaml
+var presetFiles embed.FS
+
+// presets returns the built-in presets by name. They are parsed once, on first use.
+var presets = sync.OnceValue(func() map[string]types.Language {
+	presets, err := loadPresets()
+	if err != nil {
+		// presets are embedded, so this is caught by tests
+		panic(err)
+	}
+	return presets
+})
+
+func loadPresets() (map[string]types.Language, error) {
+	entries, err := presetFiles.ReadDir("presets")
+	if err != nil {
+		return nil, err
+	}
+	presets := make(map[string]types.Language, len(entries))
+	for _, entry := range entries {
+		b, err := presetFiles.ReadFile(path.Join("presets", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		preset, err := parsePreset(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preset %s: %w", entry.Name(), err)
+		}
+		presets[strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))] = preset
+	}
+	return presets, nil
+}
+
+// parsePreset decodes a single tool definition the same way parseConfigFile decodes tools.
+func parsePreset(b []byte) (types.Language, error) {
+	var raw any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return types.Language{}, err
+	}
+	converted, err := convertConfigValue(raw, reflect.TypeFor[types.Language]())
+	if err != nil {
+		return types.Language{}, err
+	}
+	b, err = json.Marshal(converted)
+	if err != nil {
+		return types.Language{}, err
+	}
+
+	var preset types.Language
+	if err := json.Unmarshal(b, &preset); err != nil {
+		return types.Language{}, err
+	}
+	return preset, nil
+}
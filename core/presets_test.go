@@ -0,0 +1,221 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresetsLoad(t *testing.T) {
+	loaded, err := loadPresets()
+	assert.NoError(t, err)
+
+	for name, preset := range loaded {
+		assert.True(t, preset.LintCommand != "" || preset.FormatCommand != "", "preset %s runs nothing", name)
+		if preset.LintCommand != "" {
+			_, err := buildErrorformats(preset.LintFormats)
+			assert.NoError(t, err, "preset %s", name)
+			assert.True(t, slices.Contains(presetGoldenNames(), name), "preset %s has no golden output", name)
+		}
+	}
+}
+
+// presetGoldenCase is sample output of a tool and the diagnostics it should produce.
+type presetGoldenCase struct {
+	preset   string
+	filename string
+	text     string
+	// ${INPUT} is replaced with the path of the document
+	output   string
+	expected []presetDiagnostic
+}
+
+type presetDiagnostic struct {
+	line, start, end int
+	severity         types.DiagnosticSeverity
+	code             types.DiagnosticCode
+	message          string
+}
+
+var presetGoldenCases = []presetGoldenCase{
+	{
+		preset:   "eslint",
+		filename: "app.js",
+		text:     "const unused = 1;\nvar x = 2\n",
+		output: `${INPUT}:1:7: 'unused' is assigned a value but never used. [Error/no-unused-vars]
+${INPUT}:2:1: Unexpected var, use let or const instead. [Warning/no-var]
+
+2 problems
+`,
+		expected: []presetDiagnostic{
+			{0, 6, 12, types.DiagError, "", "'unused' is assigned a value but never used."},
+			{1, 0, 3, types.DiagWarning, "", "Unexpected var, use let or const instead."},
+		},
+	},
+	{
+		preset:   "flake8",
+		filename: "main.py",
+		text:     "import os\nx=1\nif True :\n    pass\n",
+		output: `stdin:1:1: F401 'os' imported but unused
+stdin:2:2: E225 missing whitespace around operator
+stdin:3:8: E203 whitespace before ':'
+stdin:4:9: W292 no newline at end of file
+`,
+		expected: []presetDiagnostic{
+			{0, 0, 6, types.DiagError, "F401", "'os' imported but unused"},
+			{1, 1, 4, types.DiagError, "E225", "missing whitespace around operator"},
+			{2, 7, 8, types.DiagError, "E203", "whitespace before ':'"},
+			{3, 8, 12, types.DiagWarning, "W292", "no newline at end of file"},
+		},
+	},
+	{
+		preset:   "hadolint",
+		filename: "Dockerfile",
+		text:     "FROM debian\nRUN apt-get install -y curl\n",
+		output: `-:1 DL3006 warning: Always tag the version of an image explicitly
+-:2 DL3008 warning: Pin versions in apt get install. Instead of ` + "`apt-get install <package>` use `apt-get install <package>=<version>`" + `
+-:2 DL3015 info: Avoid additional packages by specifying ` + "`--no-install-recommends`" + `
+-:2 DL3059 style: Multiple consecutive ` + "`RUN`" + ` instructions. Consider consolidation.
+`,
+		expected: []presetDiagnostic{
+			{0, 0, 0, types.DiagWarning, "DL3006", "Always tag the version of an image explicitly"},
+			{1, 0, 0, types.DiagWarning, "DL3008", "Pin versions in apt get install. Instead of `apt-get install <package>` use `apt-get install <package>=<version>`"},
+			{1, 0, 0, types.DiagInformation, "DL3015", "Avoid additional packages by specifying `--no-install-recommends`"},
+			{1, 0, 0, types.DiagHint, "DL3059", "Multiple consecutive `RUN` instructions. Consider consolidation."},
+		},
+	},
+	{
+		preset:   "shellcheck",
+		filename: "run.sh",
+		text:     "#!/bin/sh\nfoo=1\necho $1\n[ $# = 1 ] && [ -z $x ]\n",
+		output: `-:2:1: warning: foo appears unused. Verify use (or export if used externally). [SC2034]
+-:3:6: note: Double quote to prevent globbing and word splitting. [SC2086]
+-:4:1: error: Couldn't parse this test expression. [SC1073]
+`,
+		expected: []presetDiagnostic{
+			{1, 0, 5, types.DiagWarning, "SC2034", "foo appears unused. Verify use (or export if used externally)."},
+			{2, 5, 7, types.DiagInformation, "SC2086", "Double quote to prevent globbing and word splitting."},
+			{3, 0, 1, types.DiagError, "SC1073", "Couldn't parse this test expression."},
+		},
+	},
+	{
+		preset:   "yamllint",
+		filename: "config.yaml",
+		text:     "key: value\nkey: other   \n",
+		output: `stdin:1:1: [warning] missing document start "---" (document-start)
+stdin:2:1: [error] duplication of key "key" in mapping (key-duplicates)
+stdin:2:11: [error] trailing spaces (trailing-spaces)
+`,
+		expected: []presetDiagnostic{
+			{0, 0, 3, types.DiagWarning, "", `missing document start "---" (document-start)`},
+			{1, 0, 3, types.DiagError, "", `duplication of key "key" in mapping (key-duplicates)`},
+			{1, 10, 13, types.DiagError, "", "trailing spaces (trailing-spaces)"},
+		},
+	},
+}
+
+func presetGoldenNames() []string {
+	names := make([]string, 0, len(presetGoldenCases))
+	for _, tt := range presetGoldenCases {
+		names = append(names, tt.preset)
+	}
+	return names
+}
+
+func TestPresetGoldenOutput(t *testing.T) {
+	base, _ := os.Getwd()
+
+	for _, tt := range presetGoldenCases {
+		t.Run(tt.preset, func(t *testing.T) {
+			config, err := resolveTool(types.Language{Preset: tt.preset}, nil)
+			assert.NoError(t, err)
+
+			file := filepath.Join(base, tt.filename)
+			f := fileRef{
+				Text:               tt.text,
+				NormalizedFilename: file,
+				Uri:                ParseLocalFileToURI(file),
+			}
+			output := strings.ReplaceAll(tt.output, inputPlaceholder, file)
+
+			diagnostics, err := parseLintOutput(base, f, config, []byte(output), types.UTF16)
+			assert.NoError(t, err)
+
+			expected := make([]types.Diagnostic, 0, len(tt.expected))
+			for _, d := range tt.expected {
+				expected = append(expected, types.Diagnostic{
+					Range: types.Range{
+						Start: types.Position{Line: d.line, Character: d.start},
+						End:   types.Position{Line: d.line, Character: d.end},
+					},
+					Code:     d.code,
+					Message:  d.message,
+					Severity: d.severity,
+					Source:   &config.LintSource,
+				})
+			}
+			assert.Equal(t, expected, diagnostics)
+		})
+	}
+}
+
+func TestPresetOverrides(t *testing.T) {
+	tools := map[string]types.Language{
+		"bashcheck": {Preset: "shellcheck", LintCommand: "shellcheck --shell bash --format gcc -"},
+	}
+	configs := []types.Language{
+		{Preset: "shellcheck", LintSource: "sc"},
+		{Tool: "bashcheck"},
+		{Tool: "bashcheck", LintCommand: "shellcheck --shell bash --severity warning --format gcc -"},
+		{Preset: "missing"},
+		{Preset: "eslint", LintIgnoreExitCode: boolPtr(false)},
+	}
+
+	languages, errs := resolveLanguages(map[string][]types.Language{"sh": configs}, tools)
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "language sh, tool 3: unknown preset missing")
+	resolved := languages["sh"]
+	assert.Len(t, resolved, 4)
+
+	shellcheck := presets()["shellcheck"]
+	assert.Equal(t, "sc", resolved[0].LintSource)
+	assert.Equal(t, shellcheck.LintCommand, resolved[0].LintCommand)
+	assert.Equal(t, shellcheck.LintFormats, resolved[0].LintFormats)
+
+	assert.Equal(t, "shellcheck --shell bash --format gcc -", resolved[1].LintCommand)
+	assert.Equal(t, shellcheck.LintSource, resolved[1].LintSource)
+	assert.Equal(t, boolPtr(true), resolved[1].LintStdin)
+
+	assert.Equal(t, "shellcheck --shell bash --severity warning --format gcc -", resolved[2].LintCommand)
+	assert.Equal(t, shellcheck.LintCategoryMap, resolved[2].LintCategoryMap)
+
+	// false overrides the preset
+	assert.Equal(t, boolPtr(false), resolved[3].LintIgnoreExitCode)
+	assert.Equal(t, boolPtr(true), resolved[3].LintStdin)
+}
+
+func TestPresetsFromConfigFile(t *testing.T) {
+	config, err := parseConfigFile([]byte(`
+languages:
+  yaml:
+    - preset: yamllint
+      lint-command: yamllint --strict --format parsable -
+  dockerfile:
+    - preset: hadolint
+`))
+	assert.NoError(t, err)
+
+	h := NewHandler(config)
+	configs := h.languageConfigs()
+	assert.Equal(t, "yamllint --strict --format parsable -", configs["yaml"][0].LintCommand)
+	assert.Equal(t, presets()["yamllint"].RootMarkers, configs["yaml"][0].RootMarkers)
+
+	hadolint := presets()["hadolint"]
+	hadolint.Preset = "hadolint"
+	assert.Equal(t, []types.Language{hadolint}, configs["dockerfile"])
+}
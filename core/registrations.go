@@ -0,0 +1,105 @@
+package core
+
+import (
+	"slices"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// toolCapability is a method provided by tools that have some command configured.
+type toolCapability struct {
+	method   string
+	provided func(types.Language) bool
+}
+
+var toolCapabilities = []toolCapability{
+	{"textDocument/formatting", func(l types.Language) bool { return l.FormatCommand != "" }},
+	{"textDocument/rangeFormatting", func(l types.Language) bool { return l.FormatCommand != "" && boolOrDefault(l.FormatCanRange, false) }},
+	{"textDocument/willSaveWaitUntil", func(l types.Language) bool { return l.FormatCommand != "" && boolOrDefault(l.FormatOnSave, false) }},
+	{"textDocument/codeAction", func(l types.Language) bool { return l.FixCommand != "" }},
+	{"textDocument/completion", func(l types.Language) bool { return l.CompletionCommand != "" }},
+	{"textDocument/documentSymbol", func(l types.Language) bool { return l.SymbolCommand != "" }},
+	{"workspace/symbol", func(l types.Language) bool { return l.WorkspaceSymbolCommand != "" }},
+}
+
+// Registrations returns the capabilities backed by the configured tools and commands, and the files watched
+// by linters, for dynamic registration.
+// Document capabilities are limited to the languages that have such tools in any workspace folder,
+// or cover all documents when a wildcard tool has them. Registration ids are stable per method.
+func (h *LangHandler) Registrations() []types.Registration {
+	configs := make(map[string][]types.Language)
+	for _, scope := range h.scopedConfigs() {
+		for lang, cfgs := range scope.configs {
+			configs[lang] = append(configs[lang], cfgs...)
+		}
+	}
+	languages := make([]string, 0, len(configs))
+	for lang := range configs {
+		languages = append(languages, lang)
+	}
+	slices.Sort(languages)
+
+	registrations := make([]types.Registration, 0)
+	for _, c := range toolCapabilities {
+		var selector []types.DocumentFilter
+		provided, everywhere := false, false
+		for _, lang := range languages {
+			if !slices.ContainsFunc(configs[lang], c.provided) {
+				continue
+			}
+			provided = true
+			if lang == types.Wildcard {
+				everywhere = true
+			} else {
+				selector = append(selector, types.DocumentFilter{Language: lang})
+			}
+		}
+		if !provided {
+			continue
+		}
+		if everywhere {
+			selector = nil
+		}
+
+		documentOptions := types.TextDocumentRegistrationOptions{DocumentSelector: selector}
+		var options any = documentOptions
+		switch c.method {
+		case "textDocument/codeAction":
+			options = types.CodeActionRegistrationOptions{
+				TextDocumentRegistrationOptions: documentOptions,
+				CodeActionOptions:               types.CodeActionOptions{CodeActionKinds: codeActionKinds},
+			}
+		case "textDocument/completion":
+			options = types.CompletionRegistrationOptions{TextDocumentRegistrationOptions: documentOptions}
+		case "workspace/symbol":
+			options = nil
+		}
+		registrations = append(registrations, types.Registration{ID: registrationID(c.method), Method: c.method, RegisterOptions: options})
+	}
+
+	if len(h.configuredCommands()) > 0 {
+		registrations = append(registrations, types.Registration{
+			ID:              registrationID("workspace/executeCommand"),
+			Method:          "workspace/executeCommand",
+			RegisterOptions: types.ExecuteCommandOptions{Commands: h.CommandNames()},
+		})
+	}
+
+	if patterns := h.watchPatterns(); len(patterns) > 0 {
+		watchers := make([]types.FileSystemWatcher, 0, len(patterns))
+		for _, pattern := range patterns {
+			watchers = append(watchers, types.FileSystemWatcher{GlobPattern: pattern})
+		}
+		registrations = append(registrations, types.Registration{
+			ID:              registrationID("workspace/didChangeWatchedFiles"),
+			Method:          "workspace/didChangeWatchedFiles",
+			RegisterOptions: types.DidChangeWatchedFilesRegistrationOptions{Watchers: watchers},
+		})
+	}
+
+	return registrations
+}
+
+func registrationID(method string) string {
+	return "flint-ls/" + method
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrations(t *testing.T) {
+	documentOptions := func(languages ...string) types.TextDocumentRegistrationOptions {
+		var selector []types.DocumentFilter
+		for _, l := range languages {
+			selector = append(selector, types.DocumentFilter{Language: l})
+		}
+		return types.TextDocumentRegistrationOptions{DocumentSelector: selector}
+	}
+
+	watched := func(patterns ...string) types.Registration {
+		watchers := make([]types.FileSystemWatcher, 0, len(patterns))
+		for _, p := range patterns {
+			watchers = append(watchers, types.FileSystemWatcher{GlobPattern: p})
+		}
+		return types.Registration{
+			ID:              "flint-ls/workspace/didChangeWatchedFiles",
+			Method:          "workspace/didChangeWatchedFiles",
+			RegisterOptions: types.DidChangeWatchedFilesRegistrationOptions{Watchers: watchers},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		configs  map[string][]types.Language
+		commands []types.Command
+		want     []types.Registration
+	}{
+		{
+			name:    "no tools",
+			configs: map[string][]types.Language{"go": {{LintCommand: "vet"}}},
+			want:    []types.Registration{watched("**/.flint-ls.yaml")},
+		},
+		{
+			name: "per language selectors",
+			configs: map[string][]types.Language{
+				"python": {{FormatCommand: "black -"}, {FixCommand: "ruff --fix -"}},
+				"go":     {{FormatCommand: "gofmt", FormatOnSave: boolPtr(true)}},
+			},
+			want: []types.Registration{
+				{ID: "flint-ls/textDocument/formatting", Method: "textDocument/formatting", RegisterOptions: documentOptions("go", "python")},
+				{ID: "flint-ls/textDocument/willSaveWaitUntil", Method: "textDocument/willSaveWaitUntil", RegisterOptions: documentOptions("go")},
+				{ID: "flint-ls/textDocument/codeAction", Method: "textDocument/codeAction", RegisterOptions: types.CodeActionRegistrationOptions{
+					TextDocumentRegistrationOptions: documentOptions("python"),
+					CodeActionOptions:               types.CodeActionOptions{CodeActionKinds: codeActionKinds},
+				}},
+				watched("**/.flint-ls.yaml"),
+			},
+		},
+		{
+			name:    "watched files",
+			configs: map[string][]types.Language{"sh": {{LintCommand: "shellcheck", LintWatchFiles: []string{".shellcheckrc"}}}},
+			want:    []types.Registration{watched("**/.flint-ls.yaml", "**/.shellcheckrc")},
+		},
+		{
+			name: "wildcard covers all documents",
+			configs: map[string][]types.Language{
+				"go":           {{FormatCommand: "gofmt", FormatCanRange: boolPtr(true)}},
+				types.Wildcard: {{FormatCommand: "prettier"}, {WorkspaceSymbolCommand: "ctags"}},
+			},
+			commands: []types.Command{{Name: "build", Command: "make"}},
+			want: []types.Registration{
+				{ID: "flint-ls/textDocument/formatting", Method: "textDocument/formatting", RegisterOptions: documentOptions()},
+				{ID: "flint-ls/textDocument/rangeFormatting", Method: "textDocument/rangeFormatting", RegisterOptions: documentOptions("go")},
+				{ID: "flint-ls/workspace/symbol", Method: "workspace/symbol"},
+				{ID: "flint-ls/workspace/executeCommand", Method: "workspace/executeCommand", RegisterOptions: types.ExecuteCommandOptions{Commands: []string{"build"}}},
+				watched("**/.flint-ls.yaml"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &LangHandler{configs: tt.configs, commands: tt.commands}
+			assert.Equal(t, tt.want, h.Registrations())
+		})
+	}
+}
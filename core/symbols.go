@@ -0,0 +1,382 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// symbolEntry is a symbol as reported by a tool, lines and columns are 1-based, columns in bytes.
+type symbolEntry struct {
+	name      string
+	kind      string
+	file      string
+	container string
+	line      int
+	column    int
+}
+
+// ctagsTag is a line of universal-ctags JSON output.
+type ctagsTag struct {
+	Type  string `json:"_type"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Line  int    `json:"line"`
+	Kind  string `json:"kind"`
+	Scope string `json:"scope"`
+}
+
+var symbolKinds = map[string]types.SymbolKind{
+	"file":          types.SymbolKindFile,
+	"module":        types.SymbolKindModule,
+	"namespace":     types.SymbolKindNamespace,
+	"package":       types.SymbolKindPackage,
+	"class":         types.SymbolKindClass,
+	"method":        types.SymbolKindMethod,
+	"property":      types.SymbolKindProperty,
+	"field":         types.SymbolKindField,
+	"constructor":   types.SymbolKindConstructor,
+	"enum":          types.SymbolKindEnum,
+	"interface":     types.SymbolKindInterface,
+	"function":      types.SymbolKindFunction,
+	"variable":      types.SymbolKindVariable,
+	"constant":      types.SymbolKindConstant,
+	"string":        types.SymbolKindString,
+	"number":        types.SymbolKindNumber,
+	"boolean":       types.SymbolKindBoolean,
+	"array":         types.SymbolKindArray,
+	"object":        types.SymbolKindObject,
+	"key":           types.SymbolKindKey,
+	"null":          types.SymbolKindNull,
+	"enummember":    types.SymbolKindEnumMember,
+	"struct":        types.SymbolKindStruct,
+	"event":         types.SymbolKindEvent,
+	"operator":      types.SymbolKindOperator,
+	"typeparameter": types.SymbolKindTypeParameter,
+	// common ctags kinds
+	"func":       types.SymbolKindFunction,
+	"subroutine": types.SymbolKindFunction,
+	"target":     types.SymbolKindFunction,
+	"var":        types.SymbolKindVariable,
+	"const":      types.SymbolKindConstant,
+	"macro":      types.SymbolKindConstant,
+	"member":     types.SymbolKindField,
+	"enumerator": types.SymbolKindEnumMember,
+	"type":       types.SymbolKindClass,
+	"typedef":    types.SymbolKindClass,
+	"chapter":    types.SymbolKindString,
+	"section":    types.SymbolKindString,
+}
+
+// DocumentSymbols lists symbols of the document reported by the symbol commands of its tools.
+func (h *LangHandler) DocumentSymbols(ctx context.Context, uri types.DocumentURI) ([]types.SymbolInformation, error) {
+	f, ok := h.getFile(uri)
+	if !ok {
+		return nil, fmt.Errorf("document not found: %v", uri)
+	}
+
+	symbols := make([]types.SymbolInformation, 0)
+	for _, config := range h.documentConfigs(f) {
+		if config.SymbolCommand == "" {
+			continue
+		}
+		if dir := matchRootPath(f.NormalizedFilename, config.RootMarkers); dir == "" && boolOrDefault(config.RequireMarker, false) {
+			continue
+		}
+
+		rootPath := h.findRootPath(f.NormalizedFilename, config)
+		cmdStr := replaceMagicStrings(config.SymbolCommand, f.NormalizedFilename, rootPath)
+		entries, err := runSymbolCommand(ctx, cmdStr, rootPath, f.Text, config)
+		if err != nil {
+			logs.Log.Logln(logs.Error, err.Error())
+			continue
+		}
+		for _, entry := range entries {
+			if !isSymbolInDocument(entry, rootPath, f) {
+				continue
+			}
+			symbols = append(symbols, symbolInformation(entry, f, config, h.positionEncoding))
+		}
+	}
+
+	return symbols, nil
+}
+
+// isSymbolInDocument reports whether the symbol is reported for the document, symbols without a file
+// or reported for stdin are.
+func isSymbolInDocument(entry symbolEntry, rootPath string, f fileRef) bool {
+	if entry.file == "" || isStdinPlaceholder(entry.file) {
+		return true
+	}
+	fname := entry.file
+	if !filepath.IsAbs(fname) {
+		fname = filepath.Join(rootPath, fname)
+	}
+	return comparePaths(string(ParseLocalFileToURI(fname)), string(f.Uri))
+}
+
+// workspaceSymbolCache keeps the symbols reported by each workspace symbol command for its root,
+// so that queries are answered without running the command again.
+type workspaceSymbolCache struct {
+	mu      sync.Mutex
+	entries map[string][]symbolEntry
+	// incremented on invalidation, so that results of runs started before are not stored
+	generation int
+}
+
+func (c *workspaceSymbolCache) get(key string) ([]symbolEntry, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, ok := c.entries[key]
+	return entries, c.generation, ok
+}
+
+// store keeps entries of a run started at the given generation, unless the cache was invalidated since.
+func (c *workspaceSymbolCache) store(key string, generation int, entries []symbolEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string][]symbolEntry)
+	}
+	c.entries[key] = entries
+}
+
+// invalidate drops symbols of the roots that contain one of the files.
+func (c *workspaceSymbolCache) invalidate(fnames []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for key := range c.entries {
+		rootPath := key[strings.LastIndex(key, "\x00")+1:]
+		if slices.ContainsFunc(fnames, func(fname string) bool { return inFolder(fname, rootPath) }) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// WorkspaceSymbols lists symbols matching the query reported by the workspace symbol commands
+// for the roots of all open documents and for the workspace root. Commands run once per root,
+// and again after a file in the root is saved or changes, see WorkspaceFilesChanged.
+func (h *LangHandler) WorkspaceSymbols(ctx context.Context, query string) ([]types.SymbolInformation, error) {
+	symbols := make([]types.SymbolInformation, 0)
+	for _, w := range h.workspaceSymbolRuns() {
+		key := workspaceSymbolKey(w)
+		entries, generation, ok := h.workspaceSymbols.get(key)
+		if !ok {
+			cmdStr := replaceMagicStrings(w.config.WorkspaceSymbolCommand, "", w.rootPath)
+			var err error
+			entries, err = runSymbolCommand(ctx, cmdStr, w.rootPath, "", w.config)
+			if err != nil {
+				logs.Log.Logln(logs.Error, err.Error())
+				continue
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			h.workspaceSymbols.store(key, generation, entries)
+		}
+
+		files := make(map[types.DocumentURI]fileRef)
+		for _, entry := range entries {
+			if entry.file == "" || !matchesSymbolQuery(entry.name, query) {
+				continue
+			}
+			fname := entry.file
+			if !filepath.IsAbs(fname) {
+				fname = filepath.Join(w.rootPath, fname)
+			}
+			uri := ParseLocalFileToURI(fname)
+			f, ok := files[uri]
+			if !ok {
+				f = h.workspaceFile(uri, fname)
+				files[uri] = f
+			}
+			symbols = append(symbols, symbolInformation(entry, f, w.config, h.positionEncoding))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return symbols, nil
+}
+
+// workspaceSymbolKey identifies the output of a workspace symbol command in its root, the root comes last.
+func workspaceSymbolKey(w workspaceLint) string {
+	return w.config.WorkspaceSymbolCommand + "\x00" + w.config.SymbolPattern + "\x00" + w.rootPath
+}
+
+func (h *LangHandler) workspaceSymbolRuns() []workspaceLint {
+	runs := make([]workspaceLint, 0)
+	seen := make(map[string]bool)
+	add := func(w workspaceLint) {
+		key := workspaceSymbolKey(w)
+		if w.rootPath == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		runs = append(runs, w)
+	}
+
+	h.filesMu.RLock()
+	files := make([]fileRef, 0, len(h.files))
+	for _, f := range h.files {
+		files = append(files, *f)
+	}
+	h.filesMu.RUnlock()
+
+	for _, f := range files {
+		for _, cfg := range h.documentConfigs(f) {
+			if cfg.WorkspaceSymbolCommand != "" {
+				add(workspaceLint{rootPath: h.findRootPath(f.NormalizedFilename, cfg), config: cfg})
+			}
+		}
+	}
+	for _, scope := range h.scopedConfigs() {
+		for _, cfgs := range scope.configs {
+			for _, cfg := range cfgs {
+				if cfg.WorkspaceSymbolCommand != "" {
+					add(workspaceLint{rootPath: scope.rootPath, config: cfg})
+				}
+			}
+		}
+	}
+	return runs
+}
+
+func runSymbolCommand(ctx context.Context, cmdStr, rootPath, text string, config types.Language) ([]symbolEntry, error) {
+	pattern, err := compileSymbolPattern(config.SymbolPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := buildExecCmd(ctx, cmdStr, rootPath, text, config, true)
+	out, err := runFormattingCommand(cmd)
+
+	logs.Log.Logln(logs.Info, cmdStr)
+	logs.Log.Logln(logs.Debug, out)
+
+	if err != nil {
+		return nil, fmt.Errorf("symbol error: %s", err)
+	}
+
+	return parseSymbolOutput(out, pattern), nil
+}
+
+func compileSymbolPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid symbol pattern %q: %v", pattern, err)
+	}
+	if re.SubexpIndex("name") < 0 {
+		return nil, fmt.Errorf("symbol pattern %q has no name group", pattern)
+	}
+	return re, nil
+}
+
+// parseSymbolOutput parses universal-ctags JSON lines, or lines matching the pattern when set.
+// Symbols without a line are skipped.
+func parseSymbolOutput(out string, pattern *regexp.Regexp) []symbolEntry {
+	entries := make([]symbolEntry, 0)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), carriageReturn)
+
+		var entry symbolEntry
+		if pattern == nil {
+			var tag ctagsTag
+			if err := json.Unmarshal([]byte(line), &tag); err != nil || tag.Type != "tag" {
+				continue
+			}
+			entry = symbolEntry{name: tag.Name, kind: tag.Kind, file: tag.Path, container: tag.Scope, line: tag.Line}
+		} else {
+			match := pattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			group := func(name string) string {
+				if i := pattern.SubexpIndex(name); i >= 0 {
+					return match[i]
+				}
+				return ""
+			}
+			entry = symbolEntry{name: group("name"), kind: group("kind"), file: group("file"), container: group("container")}
+			entry.line, _ = strconv.Atoi(group("line"))
+			entry.column, _ = strconv.Atoi(group("column"))
+		}
+
+		if entry.name != "" && entry.line > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// symbolInformation locates the symbol in the file. Without a column, the name is looked up in its line.
+func symbolInformation(entry symbolEntry, f fileRef, config types.Language, encoding types.PositionEncodingKind) types.SymbolInformation {
+	lineNum := entry.line - 1
+	line := lineAt(f.Text, lineNum)
+
+	col := entry.column - 1
+	if col < 0 {
+		col = max(strings.Index(line, entry.name), 0)
+	}
+	start := convertColumn(line, col, types.ColumnUnitByte, 0, encoding)
+	end := start
+	if strings.HasPrefix(line[min(col, len(line)):], entry.name) {
+		end += encodedLen(entry.name, encoding)
+	}
+
+	return types.SymbolInformation{
+		Name: entry.name,
+		Kind: symbolKind(entry.kind, config.SymbolKindMap),
+		Location: types.Location{
+			URI: f.Uri,
+			Range: types.Range{
+				Start: types.Position{Line: lineNum, Character: start},
+				End:   types.Position{Line: lineNum, Character: end},
+			},
+		},
+		ContainerName: entry.container,
+	}
+}
+
+// symbolKind maps a kind reported by a tool, unknown kinds are reported as variables.
+func symbolKind(kind string, kindMap map[string]string) types.SymbolKind {
+	if mapped, ok := kindMap[kind]; ok {
+		kind = mapped
+	}
+	if k, ok := symbolKinds[strings.ToLower(kind)]; ok {
+		return k
+	}
+	return types.SymbolKindVariable
+}
+
+// matchesSymbolQuery reports whether the query characters appear in the name in order, ignoring case.
+func matchesSymbolQuery(name, query string) bool {
+	name = strings.ToLower(name)
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(name, r)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(string(r)):]
+	}
+	return true
+}
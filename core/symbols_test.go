@@ -0,0 +1,204 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSymbolOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		pattern  string
+		expected []symbolEntry
+	}{
+		{
+			"ctags json",
+			`{"_type": "ptag", "name": "JSON_OUTPUT_VERSION"}` + "\n" +
+				`{"_type": "tag", "name": "build", "path": "Makefile", "line": 3, "kind": "target"}` + "\n" +
+				`{"_type": "tag", "name": "nolines", "path": "Makefile", "kind": "target"}` + "\n" +
+				`{"_type": "tag", "name": "x", "path": "a.sh", "line": 7, "kind": "variable", "scope": "main"}` + "\n",
+			"",
+			[]symbolEntry{
+				{name: "build", kind: "target", file: "Makefile", line: 3},
+				{name: "x", kind: "variable", file: "a.sh", container: "main", line: 7},
+			},
+		},
+		{
+			"ctags -x",
+			"build            target        3 Makefile         build: deps\n",
+			`^(?P<name>\S+)\s+(?P<kind>\S+)\s+(?P<line>\d+)\s+(?P<file>\S+)`,
+			[]symbolEntry{
+				{name: "build", kind: "target", file: "Makefile", line: 3},
+			},
+		},
+		{
+			"column",
+			"2:5:main\nnot a symbol\n",
+			`^(?P<line>\d+):(?P<column>\d+):(?P<name>\w+)`,
+			[]symbolEntry{
+				{name: "main", line: 2, column: 5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pattern *regexp.Regexp
+			if tt.pattern != "" {
+				pattern = regexp.MustCompile(tt.pattern)
+			}
+			assert.Equal(t, tt.expected, parseSymbolOutput(tt.out, pattern))
+		})
+	}
+}
+
+func TestCompileSymbolPatternRequiresName(t *testing.T) {
+	_, err := compileSymbolPattern(`^(\w+)`)
+	assert.Error(t, err)
+}
+
+func TestSymbolInformation(t *testing.T) {
+	f := fileRef{Uri: "file:///tmp/a.sh", Text: "#!/bin/sh\nżółw() { :; }\nfoo=1\n"}
+
+	tests := []struct {
+		name     string
+		entry    symbolEntry
+		expected types.SymbolInformation
+	}{
+		{
+			"name looked up in line",
+			symbolEntry{name: "żółw", kind: "function", line: 2},
+			types.SymbolInformation{
+				Name:     "żółw",
+				Kind:     types.SymbolKindFunction,
+				Location: types.Location{URI: f.Uri, Range: types.Range{End: types.Position{Line: 1, Character: 4}, Start: types.Position{Line: 1}}},
+			},
+		},
+		{
+			"byte column",
+			symbolEntry{name: "foo", kind: "v", line: 3, column: 1},
+			types.SymbolInformation{
+				Name:     "foo",
+				Kind:     types.SymbolKindVariable,
+				Location: types.Location{URI: f.Uri, Range: types.Range{Start: types.Position{Line: 2}, End: types.Position{Line: 2, Character: 3}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, symbolInformation(tt.entry, f, types.Language{}, types.UTF16))
+		})
+	}
+}
+
+func TestSymbolKind(t *testing.T) {
+	kindMap := map[string]string{"f": "function", "t": "Struct"}
+	assert.Equal(t, types.SymbolKindFunction, symbolKind("f", kindMap))
+	assert.Equal(t, types.SymbolKindStruct, symbolKind("t", kindMap))
+	assert.Equal(t, types.SymbolKindMethod, symbolKind("Method", nil))
+	assert.Equal(t, types.SymbolKindVariable, symbolKind("unknown", nil))
+}
+
+func TestMatchesSymbolQuery(t *testing.T) {
+	assert.True(t, matchesSymbolQuery("parseSymbolOutput", ""))
+	assert.True(t, matchesSymbolQuery("parseSymbolOutput", "pso"))
+	assert.True(t, matchesSymbolQuery("parseSymbolOutput", "SYMBOL"))
+	assert.False(t, matchesSymbolQuery("parseSymbolOutput", "ops"))
+}
+
+func TestDocumentAndWorkspaceSymbols(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+	base := t.TempDir()
+	file := filepath.Join(base, "Makefile")
+	other := filepath.Join(base, "rules.mk")
+	assert.NoError(t, os.WriteFile(other, []byte("\nlint:\n"), 0644))
+	uri := ParseLocalFileToURI(file)
+
+	h := &LangHandler{
+		RootPath: base,
+		configs: map[string][]types.Language{
+			"make": {
+				{
+					SymbolCommand:          `grep -n '^[a-z]*:' | sed 's/:$//'; echo 'rules.mk:2:lint'`,
+					WorkspaceSymbolCommand: `echo 'Makefile:1:build'; echo 'rules.mk:2:lint'`,
+					SymbolPattern:          `^((?P<file>[^:]+):)?(?P<line>\d+):(?P<name>\w+)`,
+					SymbolKindMap:          map[string]string{"": "function"},
+				},
+			},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "make",
+				Text:               "build:\n\ttrue\ntest:\n",
+				NormalizedFilename: file,
+				Uri:                uri,
+			},
+		},
+	}
+
+	// symbols the command reports for other files are left out
+	symbols, err := h.DocumentSymbols(t.Context(), uri)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.SymbolInformation{
+		{Name: "build", Kind: types.SymbolKindFunction, Location: types.Location{URI: uri, Range: types.Range{Start: types.Position{Line: 0}, End: types.Position{Line: 0, Character: 5}}}},
+		{Name: "test", Kind: types.SymbolKindFunction, Location: types.Location{URI: uri, Range: types.Range{Start: types.Position{Line: 2}, End: types.Position{Line: 2, Character: 4}}}},
+	}, symbols)
+
+	symbols, err = h.WorkspaceSymbols(t.Context(), "lnt")
+	assert.NoError(t, err)
+	assert.Equal(t, []types.SymbolInformation{
+		{Name: "lint", Kind: types.SymbolKindFunction, Location: types.Location{URI: ParseLocalFileToURI(other), Range: types.Range{Start: types.Position{Line: 1}, End: types.Position{Line: 1, Character: 4}}}},
+	}, symbols)
+}
+
+func TestWorkspaceSymbolsAreCachedPerRoot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+	base := t.TempDir()
+	rules := filepath.Join(base, "rules.mk")
+	assert.NoError(t, os.WriteFile(rules, []byte("lint:\n"), 0644))
+
+	h := &LangHandler{
+		RootPath: base,
+		configs: map[string][]types.Language{
+			"make": {
+				{
+					WorkspaceSymbolCommand: `grep -Hn '^[a-z]*:' rules.mk | sed 's/:$//'`,
+					SymbolPattern:          `^(?P<file>[^:]+):(?P<line>\d+):(?P<name>\w+)`,
+				},
+			},
+		},
+	}
+	names := func(query string) []string {
+		symbols, err := h.WorkspaceSymbols(t.Context(), query)
+		assert.NoError(t, err)
+		names := make([]string, 0, len(symbols))
+		for _, s := range symbols {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"lint"}, names(""))
+	assert.NoError(t, os.WriteFile(rules, []byte("lint:\nvet:\n"), 0644))
+	assert.Equal(t, []string{"lint"}, names(""))
+
+	// files outside of the root keep the symbols
+	h.WorkspaceFilesChanged([]types.DocumentURI{ParseLocalFileToURI(filepath.Join(filepath.Dir(base), "other.mk"))})
+	assert.Equal(t, []string{"lint"}, names(""))
+
+	h.WorkspaceFilesChanged([]types.DocumentURI{ParseLocalFileToURI(rules)})
+	assert.Equal(t, []string{"lint", "vet"}, names(""))
+	assert.Equal(t, []string{"vet"}, names("v"))
+}
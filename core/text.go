@@ -0,0 +1,151 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// applyContentChanges applies changes in order, each one to the result of the previous.
+// A change without a range replaces the whole text.
+func applyContentChanges(text string, changes []types.TextDocumentContentChangeEvent, encoding types.PositionEncodingKind) (string, error) {
+	for _, change := range changes {
+		if change.Range == nil {
+			text = change.Text
+			continue
+		}
+
+		start := positionToOffset(text, change.Range.Start, encoding)
+		end := positionToOffset(text, change.Range.End, encoding)
+		if start > end {
+			return "", fmt.Errorf("invalid range: start %v is after end %v", change.Range.Start, change.Range.End)
+		}
+		text = text[:start] + change.Text + text[end:]
+	}
+	return text, nil
+}
+
+// lineStart returns the byte offset of the start of the line containing offset.
+func lineStart(text string, offset int) int {
+	return strings.LastIndexByte(text[:offset], '\n') + 1
+}
+
+// positionToOffset converts a 0-based line and character position, expressed in the given encoding,
+// into a byte offset in text.
+// Positions past the end of a line are clamped to the end of that line,
+// positions past the last line are clamped to the end of text.
+func positionToOffset(text string, pos types.Position, encoding types.PositionEncodingKind) int {
+	offset := 0
+	for range max(pos.Line, 0) {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+
+	line := text[offset:]
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	units := 0
+	for i, r := range line {
+		if units >= pos.Character {
+			return offset + i
+		}
+		units += runeUnits(r, encoding)
+	}
+	return offset + len(line)
+}
+
+// runeUnits returns the number of code units needed to encode r in the given encoding.
+// Unknown encodings are treated as utf-16, which is the LSP default.
+func runeUnits(r rune, encoding types.PositionEncodingKind) int {
+	switch encoding {
+	case types.UTF8:
+		return utf8.RuneLen(r)
+	case types.UTF32:
+		return 1
+	default:
+		if r >= 0x10000 {
+			// encoded as a surrogate pair
+			return 2
+		}
+		return 1
+	}
+}
+
+// encodedLen returns the number of code units needed to encode s in the given encoding.
+func encodedLen(s string, encoding types.PositionEncodingKind) int {
+	if encoding == types.UTF8 {
+		return len(s)
+	}
+	n := 0
+	for _, r := range s {
+		n += runeUnits(r, encoding)
+	}
+	return n
+}
+
+const defaultTabWidth = 8
+
+// convertColumn converts a 0-based column on line, reported by a linter in the given unit,
+// into a column in the given position encoding.
+// Columns past the end of the line keep their distance from the end of the line.
+func convertColumn(line string, col int, unit types.ColumnUnit, tabWidth int, encoding types.PositionEncodingKind) int {
+	if unitMatchesEncoding(unit, encoding) {
+		return col
+	}
+	if tabWidth <= 0 {
+		tabWidth = defaultTabWidth
+	}
+
+	units := 0
+	for i, r := range line {
+		var width int
+		switch unit {
+		case types.ColumnUnitByte:
+			width = utf8.RuneLen(r)
+		case types.ColumnUnitRune:
+			width = 1
+		case types.ColumnUnitDisplay:
+			width = 1
+			if r == '\t' {
+				width = tabWidth - units%tabWidth
+			}
+		default:
+			width = runeUnits(r, types.UTF16)
+		}
+		// a column pointing into the middle of a rune (or an expanded tab) points at that rune
+		if units+width > col {
+			return encodedLen(line[:i], encoding)
+		}
+		units += width
+	}
+	return encodedLen(line, encoding) + col - units
+}
+
+func unitMatchesEncoding(unit types.ColumnUnit, encoding types.PositionEncodingKind) bool {
+	switch unit {
+	case types.ColumnUnitByte:
+		return encoding == types.UTF8
+	case types.ColumnUnitRune:
+		return encoding == types.UTF32
+	case types.ColumnUnitDisplay:
+		return false
+	default:
+		return encoding == types.UTF16 || encoding == ""
+	}
+}
+
+// lineAt returns the n-th 0-based line of text, or an empty string if there is no such line.
+func lineAt(text string, n int) string {
+	lines := strings.SplitN(text, "\n", n+2)
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+	return lines[n]
+}
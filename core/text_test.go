@@ -0,0 +1,148 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func rangePtr(startLine, startChar, endLine, endChar int) *types.Range {
+	return &types.Range{
+		Start: types.Position{Line: startLine, Character: startChar},
+		End:   types.Position{Line: endLine, Character: endChar},
+	}
+}
+
+func TestPositionToOffset(t *testing.T) {
+	text := "ab\ncafé 😊x\n"
+	tests := []struct {
+		name     string
+		pos      types.Position
+		expected int
+	}{
+		{"start", types.Position{Line: 0, Character: 0}, 0},
+		{"end of first line", types.Position{Line: 0, Character: 2}, 2},
+		{"past end of line is clamped", types.Position{Line: 0, Character: 10}, 2},
+		{"second line start", types.Position{Line: 1, Character: 0}, 3},
+		{"after multibyte rune", types.Position{Line: 1, Character: 4}, 8},
+		{"after surrogate pair", types.Position{Line: 1, Character: 7}, 13},
+		{"last empty line", types.Position{Line: 2, Character: 0}, 15},
+		{"past last line is clamped", types.Position{Line: 5, Character: 0}, 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, positionToOffset(text, tt.pos, types.UTF16))
+		})
+	}
+}
+
+func TestPositionToOffsetEncodings(t *testing.T) {
+	text := "😊é|"
+	assert.Equal(t, 6, positionToOffset(text, types.Position{Line: 0, Character: 6}, types.UTF8))
+	assert.Equal(t, 6, positionToOffset(text, types.Position{Line: 0, Character: 3}, types.UTF16))
+	assert.Equal(t, 6, positionToOffset(text, types.Position{Line: 0, Character: 2}, types.UTF32))
+}
+
+func TestApplyContentChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		changes  []types.TextDocumentContentChangeEvent
+		expected string
+	}{
+		{
+			name:     "full text",
+			text:     "old",
+			changes:  []types.TextDocumentContentChangeEvent{{Text: "new"}},
+			expected: "new",
+		},
+		{
+			name:     "insert",
+			text:     "hello world\n",
+			changes:  []types.TextDocumentContentChangeEvent{{Range: rangePtr(0, 5, 0, 5), Text: ","}},
+			expected: "hello, world\n",
+		},
+		{
+			name:     "delete across lines",
+			text:     "one\ntwo\nthree\n",
+			changes:  []types.TextDocumentContentChangeEvent{{Range: rangePtr(0, 3, 2, 0), Text: "\n"}},
+			expected: "one\nthree\n",
+		},
+		{
+			name: "multiple changes applied in order",
+			text: "foo\nbar\n",
+			changes: []types.TextDocumentContentChangeEvent{
+				{Range: rangePtr(0, 0, 0, 3), Text: "baz"},
+				{Range: rangePtr(1, 3, 1, 3), Text: "!"},
+				// refers to the text after the previous changes
+				{Range: rangePtr(1, 0, 1, 4), Text: "qux"},
+				{Range: rangePtr(2, 0, 2, 0), Text: "end\n"},
+			},
+			expected: "baz\nqux\nend\n",
+		},
+		{
+			name: "utf16 offsets",
+			text: "😊 café\n",
+			changes: []types.TextDocumentContentChangeEvent{
+				{Range: rangePtr(0, 3, 0, 7), Text: "tea"},
+				{Range: rangePtr(0, 0, 0, 2), Text: ":)"},
+			},
+			expected: ":) tea\n",
+		},
+		{
+			name: "full text followed by incremental",
+			text: "old",
+			changes: []types.TextDocumentContentChangeEvent{
+				{Text: "new\n"},
+				{Range: rangePtr(0, 0, 0, 0), Text: "brand "},
+			},
+			expected: "brand new\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := applyContentChanges(tt.text, tt.changes, types.UTF16)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, text)
+		})
+	}
+}
+
+func TestApplyContentChangesInvalidRange(t *testing.T) {
+	_, err := applyContentChanges("hello", []types.TextDocumentContentChangeEvent{{Range: rangePtr(0, 4, 0, 1), Text: ""}}, types.UTF16)
+	assert.Error(t, err)
+}
+
+func TestConvertColumn(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		col      int
+		unit     types.ColumnUnit
+		tabWidth int
+		encoding types.PositionEncodingKind
+		expected int
+	}{
+		{"utf16 to utf16 is unchanged", "😊x", 2, types.ColumnUnitUTF16, 0, types.UTF16, 2},
+		{"default unit is utf16", "😊x", 2, "", 0, types.UTF8, 4},
+		{"bytes to utf16", "café x", 6, types.ColumnUnitByte, 0, types.UTF16, 5},
+		{"bytes to utf8 is unchanged", "café x", 6, types.ColumnUnitByte, 0, types.UTF8, 6},
+		{"bytes in the middle of a rune", "é", 1, types.ColumnUnitByte, 0, types.UTF16, 0},
+		{"runes to utf16", "😊 x", 2, types.ColumnUnitRune, 0, types.UTF16, 3},
+		{"runes to utf8", "😊 x", 2, types.ColumnUnitRune, 0, types.UTF8, 5},
+		{"display with default tab width", "\tx", 8, types.ColumnUnitDisplay, 0, types.UTF16, 1},
+		{"display with custom tab width", "\t\tx", 4, types.ColumnUnitDisplay, 2, types.UTF16, 2},
+		{"display inside a tab", "a\tx", 3, types.ColumnUnitDisplay, 4, types.UTF16, 1},
+		{"display after partial tab", "ab\tx", 4, types.ColumnUnitDisplay, 4, types.UTF16, 3},
+		{"past end of line", "ab", 5, types.ColumnUnitByte, 0, types.UTF16, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, convertColumn(tt.line, tt.col, tt.unit, tt.tabWidth, tt.encoding))
+		})
+	}
+}
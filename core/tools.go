@@ -0,0 +1,67 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// resolveTool applies the tool definition and the preset the config refers to.
+// Fields set in the config override those of its tool definition, which override those of the preset.
+func resolveTool(cfg types.Language, tools map[string]types.Language) (types.Language, error) {
+	if cfg.Tool != "" {
+		tool, ok := tools[cfg.Tool]
+		if !ok {
+			return types.Language{}, fmt.Errorf("unknown tool %s", cfg.Tool)
+		}
+		cfg = overrideLanguage(tool, cfg)
+	}
+	if cfg.Preset != "" {
+		preset, ok := presets()[cfg.Preset]
+		if !ok {
+			return types.Language{}, fmt.Errorf("unknown preset %s", cfg.Preset)
+		}
+		cfg = overrideLanguage(preset, cfg)
+	}
+	return cfg, nil
+}
+
+// resolveLanguages resolves references to tool definitions and presets of all languages and validates the tools.
+// Tools with problems are left out, the problems name the language and the index of the tool in it.
+func resolveLanguages(configs map[string][]types.Language, tools map[string]types.Language) (map[string][]types.Language, []error) {
+	resolved := make(map[string][]types.Language, len(configs))
+	var errs []error
+	for _, lang := range slices.Sorted(maps.Keys(configs)) {
+		resolved[lang] = make([]types.Language, 0, len(configs[lang]))
+		for i, cfg := range configs[lang] {
+			cfg, err := resolveTool(cfg, tools)
+			problems := validateLanguage(cfg)
+			if err != nil {
+				problems = []error{err}
+			}
+			for _, err := range problems {
+				errs = append(errs, fmt.Errorf("language %s, tool %d: %w", lang, i, err))
+			}
+			if len(problems) == 0 {
+				resolved[lang] = append(resolved[lang], cfg)
+			}
+		}
+	}
+	return resolved, errs
+}
+
+// overrideLanguage returns base with the fields set in override replacing its own.
+// Options that can be turned off are pointers, so that false and 0 override too.
+func overrideLanguage(base, override types.Language) types.Language {
+	b := reflect.ValueOf(&base).Elem()
+	o := reflect.ValueOf(override)
+	for i := range o.NumField() {
+		if field := o.Field(i); !field.IsZero() {
+			b.Field(i).Set(field)
+		}
+	}
+	return base
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveLanguages(t *testing.T) {
+	lintAfterOpen := false
+	tools := map[string]types.Language{
+		"shellcheck": {LintCommand: "shellcheck -f gcc -", LintStdin: boolPtr(true), LintFormats: []string{"%f:%l:%c: %m"}, LintSource: "shellcheck"},
+		"prettier":   {FormatCommand: "prettier --stdin-filepath ${INPUT}", RootMarkers: []string{".prettierrc"}},
+	}
+	configs := map[string][]types.Language{
+		"sh": {{Tool: "shellcheck"}},
+		"bash": {
+			{Tool: "shellcheck", LintCommand: "shellcheck -s bash -f gcc -", LintAfterOpen: &lintAfterOpen},
+			{Tool: "missing"},
+		},
+		types.Wildcard: {{Tool: "prettier"}, {FormatCommand: "cat"}},
+	}
+
+	tests := []struct {
+		lang     string
+		expected []types.Language
+	}{
+		{"sh", []types.Language{
+			{Tool: "shellcheck", LintCommand: "shellcheck -f gcc -", LintStdin: boolPtr(true), LintFormats: []string{"%f:%l:%c: %m"}, LintSource: "shellcheck"},
+			{Tool: "prettier", FormatCommand: "prettier --stdin-filepath ${INPUT}", RootMarkers: []string{".prettierrc"}},
+			{FormatCommand: "cat"},
+		}},
+		{"bash", []types.Language{
+			{Tool: "shellcheck", LintCommand: "shellcheck -s bash -f gcc -", LintStdin: boolPtr(true), LintFormats: []string{"%f:%l:%c: %m"}, LintSource: "shellcheck", LintAfterOpen: &lintAfterOpen},
+			{Tool: "prettier", FormatCommand: "prettier --stdin-filepath ${INPUT}", RootMarkers: []string{".prettierrc"}},
+			{FormatCommand: "cat"},
+		}},
+	}
+	resolved, errs := resolveLanguages(configs, tools)
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "language bash, tool 1: unknown tool missing")
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			assert.Equal(t, tt.expected, getAllConfigsForLang(resolved, tt.lang))
+		})
+	}
+
+	// definitions are not changed by overrides
+	assert.Equal(t, "shellcheck -f gcc -", tools["shellcheck"].LintCommand)
+}
+
+func TestOverrideWithFalseAndZero(t *testing.T) {
+	tools := map[string]types.Language{
+		"vint": {LintCommand: "vint -", LintStdin: boolPtr(true), RequireMarker: boolPtr(true), LintOffset: intPtr(1)},
+	}
+	config := types.Language{Tool: "vint", LintCommand: "vint ${INPUT}", LintStdin: boolPtr(false), LintOffset: intPtr(0)}
+
+	resolved, err := resolveTool(config, tools)
+	assert.NoError(t, err)
+	assert.Equal(t, types.Language{
+		Tool: "vint", LintCommand: "vint ${INPUT}", LintStdin: boolPtr(false), RequireMarker: boolPtr(true), LintOffset: intPtr(0),
+	}, resolved)
+}
+
+func TestToolsFromConfigFile(t *testing.T) {
+	config, err := parseConfigFile([]byte(`
+tools:
+  eslint:
+    lint-command: eslint -f unix --stdin
+    lint-stdin: true
+languages:
+  javascript:
+    - tool: eslint
+  typescript:
+    - tool: eslint
+      lint-source: eslint-ts
+  vue:
+    - tool: eslint
+      lint-command: eslint -f unix ${INPUT}
+      lint-stdin: false
+`))
+	assert.NoError(t, err)
+
+	h := NewHandler(config)
+	assert.Equal(t, map[string][]types.Language{
+		"javascript": {{Tool: "eslint", LintCommand: "eslint -f unix --stdin", LintStdin: boolPtr(true)}},
+		"typescript": {{Tool: "eslint", LintCommand: "eslint -f unix --stdin", LintStdin: boolPtr(true), LintSource: "eslint-ts"}},
+		"vue":        {{Tool: "eslint", LintCommand: "eslint -f unix ${INPUT}", LintStdin: boolPtr(false)}},
+	}, h.languageConfigs())
+
+	// the client can redefine a tool for all languages that use it
+	tools := map[string]types.Language{"eslint": {LintCommand: "eslint_d -f unix --stdin", LintStdin: boolPtr(true)}}
+	h.UpdateConfiguration(&types.Config{Tools: &tools})
+	assert.Equal(t, "eslint_d -f unix --stdin", h.languageConfigs()["typescript"][0].LintCommand)
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// ToolTrace observes the tools run on behalf of a request.
+// Like httptrace, it is attached to the context of the request and its hooks may be called concurrently.
+type ToolTrace struct {
+	// ToolStart is called before a tool runs, with its name and the file it runs for, empty for workspace tools.
+	ToolStart func(tool, filename string)
+	// ToolDone is called after the tool finished, err is why it failed if it did.
+	ToolDone func(tool, filename string, err error)
+}
+
+type toolTraceKey struct{}
+
+// WithToolTrace returns a context that reports tool runs to the trace.
+func WithToolTrace(ctx context.Context, trace *ToolTrace) context.Context {
+	return context.WithValue(ctx, toolTraceKey{}, trace)
+}
+
+// traceTool reports the start of a tool and returns the function reporting its end.
+func traceTool(ctx context.Context, config types.Language, command, filename string) func(err error) {
+	trace, _ := ctx.Value(toolTraceKey{}).(*ToolTrace)
+	if trace == nil {
+		return func(error) {}
+	}
+
+	tool := toolName(config, command)
+	if trace.ToolStart != nil {
+		trace.ToolStart(tool, filename)
+	}
+	return func(err error) {
+		if trace.ToolDone != nil {
+			trace.ToolDone(tool, filename, err)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/konradmalik/flint-ls/types"
 )
@@ -19,6 +20,10 @@ const (
 	carriageReturn      = "\r"
 )
 
+// waitDelay bounds how long a cancelled command may hold its output open,
+// e.g. when the shell was killed but a child process it started is still running.
+const waitDelay = 100 * time.Millisecond
+
 func normalizedFilenameFromUri(uri types.DocumentURI) (string, error) {
 	fname, err := PathFromURI(uri)
 	if err != nil {
@@ -28,6 +33,7 @@ func normalizedFilenameFromUri(uri types.DocumentURI) (string, error) {
 	return fname, nil
 }
 
+// getAllConfigsForLang returns the tools of the language and the wildcard tools.
 func getAllConfigsForLang(allConfigs map[string][]types.Language, langId string) []types.Language {
 	configsForLang := make([]types.Language, 0)
 	if cfgs, ok := allConfigs[langId]; ok {
@@ -42,6 +48,8 @@ func getAllConfigsForLang(allConfigs map[string][]types.Language, langId string)
 func buildExecCmd(ctx context.Context, command, rootPath string, textToFormat string, config types.Language, stdin bool) *exec.Cmd {
 	cmd := exec.CommandContext(ctx, shell, shellFlag, command)
 	cmd.Dir = rootPath
+	cmd.WaitDelay = waitDelay
+	killProcessGroupOnCancel(cmd)
 	cmd.Env = append(os.Environ(), config.Env...)
 	if stdin {
 		cmd.Stdin = strings.NewReader(textToFormat)
@@ -50,13 +58,6 @@ func buildExecCmd(ctx context.Context, command, rootPath string, textToFormat st
 	return cmd
 }
 
-func itoaPtrIfNotZero(n int) *int {
-	if n == 0 {
-		return nil
-	}
-	return &n
-}
-
 func boolOrDefault(b *bool, def bool) bool {
 	if b == nil {
 		return def
@@ -65,3 +66,12 @@ func boolOrDefault(b *bool, def bool) bool {
 }
 
 func boolPtr(v bool) *bool { return &v }
+
+func intOrDefault(n *int, def int) int {
+	if n == nil {
+		return def
+	}
+	return *n
+}
+
+func intPtr(v int) *int { return &v }
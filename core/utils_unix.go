@@ -2,6 +2,11 @@
 
 package core
 
+import (
+	"os/exec"
+	"syscall"
+)
+
 const (
 	shell     = "sh"
 	shellFlag = "-c"
@@ -10,3 +15,12 @@ const (
 func comparePaths(path1, path2 string) bool {
 	return path1 == path2
 }
+
+// killProcessGroupOnCancel starts the command in its own process group and kills the whole group
+// when the context is cancelled, so that tools started by the shell do not outlive it.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -3,6 +3,7 @@
 package core
 
 import (
+	"os/exec"
 	"strings"
 )
 
@@ -14,3 +15,6 @@ const (
 func comparePaths(path1, path2 string) bool {
 	return strings.EqualFold(path1, path2)
 }
+
+// killProcessGroupOnCancel keeps the default behaviour on windows, only the shell is killed.
+func killProcessGroupOnCancel(_ *exec.Cmd) {}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+var (
+	// ${NAME}, or ${--flag:opt} and ${--flag=opt} of formatCommand
+	rePlaceholder = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+	magicPlaceholders = []string{inputPlaceholder, fileextPlaceholder, filenamePlaceholder, rootPlaceholder}
+	// placeholders that only some commands fill in
+	commandPlaceholders = []string{rowPlaceholder, colPlaceholder, codePlaceholder}
+
+	columnUnits    = []types.ColumnUnit{types.ColumnUnitByte, types.ColumnUnitRune, types.ColumnUnitUTF16, types.ColumnUnitDisplay}
+	commandOutputs = []types.CommandOutput{types.CommandOutputIgnore, types.CommandOutputApply, types.CommandOutputMessage}
+)
+
+// validateLanguage returns the problems of a resolved tool, which make it unusable.
+// Error formats are compiled, so that lint runs use the compiled ones.
+func validateLanguage(cfg types.Language) []error {
+	var errs []error
+	if cfg.LintCommand != "" {
+		if _, err := buildErrorformats(cfg.LintFormats); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cfg.LintColumnUnit != "" && !slices.Contains(columnUnits, cfg.LintColumnUnit) {
+		errs = append(errs, fmt.Errorf("lintColumnUnit: unknown unit %s, expected one of %s", cfg.LintColumnUnit, joinValues(columnUnits)))
+	}
+	check := func(field, command string, extra ...string) {
+		for _, err := range checkPlaceholders(command, field == "formatCommand", extra...) {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+		}
+	}
+	check("lintCommand", cfg.LintCommand)
+	if boolOrDefault(cfg.LintWorkspace, false) {
+		// workspace linters run for the whole root, without a file
+		for _, placeholder := range []string{inputPlaceholder, fileextPlaceholder, filenamePlaceholder} {
+			if strings.Contains(cfg.LintCommand, placeholder) {
+				errs = append(errs, fmt.Errorf("lintCommand: placeholder %s is not available here", placeholder))
+			}
+		}
+	}
+	check("formatCommand", cfg.FormatCommand)
+	check("fixCommand", cfg.FixCommand)
+	check("explainCommand", cfg.ExplainCommand, codePlaceholder)
+	check("completionCommand", cfg.CompletionCommand, rowPlaceholder, colPlaceholder)
+	check("symbolCommand", cfg.SymbolCommand)
+	check("workspaceSymbolCommand", cfg.WorkspaceSymbolCommand)
+	return errs
+}
+
+// checkPlaceholders reports placeholders the command does not fill in, and placeholders that are not closed.
+// Other ${NAME} are left for the shell, except in formatCommand, which removes them.
+func checkPlaceholders(command string, format bool, extra ...string) []error {
+	var errs []error
+	for _, match := range rePlaceholder.FindAllStringSubmatch(command, -1) {
+		placeholder, name := match[0], match[1]
+		if slices.Contains(magicPlaceholders, placeholder) || slices.Contains(extra, placeholder) {
+			continue
+		}
+		if format && strings.ContainsAny(name, ":=") {
+			// formatting options and ranges
+			continue
+		}
+		switch {
+		case slices.Contains(commandPlaceholders, placeholder):
+			errs = append(errs, fmt.Errorf("placeholder %s is not available here", placeholder))
+		case format || name == "" || isMisspelledPlaceholder(placeholder):
+			errs = append(errs, fmt.Errorf("unknown placeholder %s", placeholder))
+		}
+	}
+	if strings.Contains(rePlaceholder.ReplaceAllString(command, ""), "${") {
+		errs = append(errs, errors.New("placeholder without closing }"))
+	}
+	return errs
+}
+
+// isMisspelledPlaceholder reports whether the placeholder differs from a known one only by case, like ${input}.
+func isMisspelledPlaceholder(placeholder string) bool {
+	for _, known := range slices.Concat(magicPlaceholders, commandPlaceholders) {
+		if strings.EqualFold(placeholder, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCommands returns the problems of commands, naming the command.
+func validateCommands(commands []types.Command) []error {
+	var errs []error
+	for _, command := range commands {
+		for _, err := range checkPlaceholders(command.Command, false) {
+			errs = append(errs, fmt.Errorf("command %s: %w", command.Name, err))
+		}
+		if command.Output != "" && !slices.Contains(commandOutputs, command.Output) {
+			errs = append(errs, fmt.Errorf("command %s: unknown output %s, expected one of %s", command.Name, command.Output, joinValues(commandOutputs)))
+		}
+	}
+	return errs
+}
+
+// joinValues lists allowed values of a setting.
+func joinValues[T ~string](values []T) string {
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		names = append(names, string(v))
+	}
+	return strings.Join(names, ", ")
+}
+
+// UnknownConfigFields reports keys of client settings that are not part of the configuration, naming the
+// language and the index of the tool they are set for. Settings with such keys are rejected.
+func UnknownConfigFields(settings json.RawMessage) error {
+	var raw map[string]any
+	if err := json.Unmarshal(settings, &raw); err != nil {
+		// not an object, decoding the settings fails with a better error
+		return nil
+	}
+	return unknownConfigFields(raw)
+}
+
+func unknownConfigFields(settings map[string]any) error {
+	configType := reflect.TypeFor[types.Config]()
+	languageType := reflect.TypeFor[types.Language]()
+	commandType := reflect.TypeFor[types.Command]()
+
+	errs := unknownFields(settings, configType, "")
+	if languages, ok := settings["languages"].(map[string]any); ok {
+		for _, lang := range slices.Sorted(maps.Keys(languages)) {
+			entries, _ := languages[lang].([]any)
+			for i, entry := range entries {
+				errs = append(errs, unknownFields(entry, languageType, fmt.Sprintf("language %s, tool %d: ", lang, i))...)
+			}
+		}
+	}
+	if tools, ok := settings["tools"].(map[string]any); ok {
+		for _, name := range slices.Sorted(maps.Keys(tools)) {
+			errs = append(errs, unknownFields(tools[name], languageType, fmt.Sprintf("tool %s: ", name))...)
+		}
+	}
+	if commands, ok := settings["commands"].([]any); ok {
+		for i, command := range commands {
+			errs = append(errs, unknownFields(command, commandType, fmt.Sprintf("command %d: ", i))...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unknownFields returns an error for each key of the object that is not a json name of a field of the struct.
+func unknownFields(value any, typ reflect.Type, where string) []error {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(object)) {
+		if _, ok := jsonField(typ, key); !ok {
+			errs = append(errs, fmt.Errorf("%sunknown field %s", where, key))
+		}
+	}
+	return errs
+}
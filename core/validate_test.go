@@ -0,0 +1,115 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   types.Language
+		expected []string
+	}{
+		{"valid", types.Language{LintCommand: "shellcheck -f gcc ${INPUT}", LintFormats: []string{"%f:%l:%c: %m"}}, nil},
+		{"default formats", types.Language{LintCommand: "vint -"}, nil},
+		{"invalid error format", types.Language{LintCommand: "vint -", LintFormats: []string{"%f:%l:%y"}},
+			[]string{"invalid error-format: [%f:%l:%y]: E376: Invalid %y in format string prefix"}},
+		{"formats of no linter", types.Language{LintFormats: []string{"%f:%l:%y"}}, nil},
+		{"shell variables", types.Language{LintCommand: "eslint --cache-location ${XDG_CACHE_HOME}/eslint ${INPUT}"}, nil},
+		{"format options", types.Language{FormatCommand: "prettier ${--tab-width:tabSize} ${--range-start=charStart} ${INPUT}"}, nil},
+		{"unknown format placeholder", types.Language{FormatCommand: "prettier ${TABSIZE}"},
+			[]string{"formatCommand: unknown placeholder ${TABSIZE}"}},
+		{"misspelled placeholder", types.Language{LintCommand: "vint ${input}"},
+			[]string{"lintCommand: unknown placeholder ${input}"}},
+		{"empty placeholder", types.Language{FixCommand: "fix ${}"},
+			[]string{"fixCommand: unknown placeholder ${}"}},
+		{"placeholder of another command", types.Language{LintCommand: "vint ${ROW}", ExplainCommand: "explain ${CODE}"},
+			[]string{"lintCommand: placeholder ${ROW} is not available here"}},
+		{"command placeholders", types.Language{CompletionCommand: "complete ${ROW}:${COL}", ExplainCommand: "explain ${CODE}"}, nil},
+		{"unclosed placeholder", types.Language{SymbolCommand: "ctags ${INPUT"},
+			[]string{"symbolCommand: placeholder without closing }"}},
+		{"workspace linter", types.Language{LintCommand: "golangci-lint run ${ROOT}/...", LintWorkspace: boolPtr(true)}, nil},
+		{"file of workspace linter", types.Language{LintCommand: "eslint ${INPUT}", LintWorkspace: boolPtr(true)},
+			[]string{"lintCommand: placeholder ${INPUT} is not available here"}},
+		{"column unit", types.Language{LintCommand: "vint -", LintColumnUnit: types.ColumnUnitDisplay}, nil},
+		{"unknown column unit", types.Language{LintCommand: "vint -", LintColumnUnit: "chars"},
+			[]string{"lintColumnUnit: unknown unit chars, expected one of byte, rune, utf16, display"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []string
+			for _, err := range validateLanguage(tt.config) {
+				messages = append(messages, err.Error())
+			}
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+}
+
+func TestInvalidToolsAreReportedAndSkipped(t *testing.T) {
+	h := NewHandler(NewConfig())
+
+	languages := map[string][]types.Language{
+		"sh": {
+			{LintCommand: "shellcheck -f gcc -", LintStdin: boolPtr(true)},
+			{LintCommand: "shellcheck -f gcc -", LintFormats: []string{"%f:%l:%y"}},
+			{Tool: "missing"},
+		},
+		"yaml": {{Preset: "yamlint"}, {Preset: "yamllint", LintCommand: "yamllint ${ROW}"}},
+	}
+	commands := []types.Command{{Name: "build", Command: "make ${INPUT"}, {Name: "fmt", Command: "gofmt ${INPUT}", Output: "replace"}}
+	err := h.UpdateConfiguration(&types.Config{Languages: &languages, Commands: &commands})
+	assert.EqualError(t, err, `language sh, tool 1: invalid error-format: [%f:%l:%y]: E376: Invalid %y in format string prefix
+language sh, tool 2: unknown tool missing
+language yaml, tool 0: unknown preset yamlint
+language yaml, tool 1: lintCommand: placeholder ${ROW} is not available here
+command build: placeholder without closing }
+command fmt: unknown output replace, expected one of ignore, apply, message`)
+
+	// the valid tool still runs
+	assert.Equal(t, map[string][]types.Language{
+		"sh":   {{LintCommand: "shellcheck -f gcc -", LintStdin: boolPtr(true)}},
+		"yaml": {},
+	}, h.languageConfigs())
+
+	languages = map[string][]types.Language{"sh": {{LintCommand: "shellcheck -f gcc -", LintStdin: boolPtr(true)}}}
+	assert.NoError(t, h.UpdateConfiguration(&types.Config{Languages: &languages, Commands: &[]types.Command{}}))
+
+	// problems are reported per folder
+	folderLanguages := map[string][]types.Language{"go": {{Tool: "golangci"}}}
+	err = h.UpdateFolderConfiguration("/work", &types.Config{Languages: &folderLanguages})
+	assert.EqualError(t, err, "folder /work, language go, tool 0: unknown tool golangci")
+}
+
+func TestUnknownConfigFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		expected string
+	}{
+		{"valid", `{"rootMarkers": [".git/"], "languages": {"go": [{"formatCommand": "gofmt"}]}}`, ""},
+		{"null", `null`, ""},
+		{"not an object", `[]`, ""},
+		{"top level", `{"rootMarker": [".git/"]}`, "unknown field rootMarker"},
+		{"language", `{"languages": {"go": [{"formatCommand": "gofmt"}, {"lintComand": "vet", "lintStdin": true}]}}`,
+			"language go, tool 1: unknown field lintComand"},
+		{"tool", `{"tools": {"eslint": {"lintCommand": "eslint", "lint-stdin": true}}}`, "tool eslint: unknown field lint-stdin"},
+		{"command", `{"commands": [{"name": "build", "cmd": "make"}]}`, "command 0: unknown field cmd"},
+		{"sorted", `{"languages": {"sh": [{"b": 1, "a": 2}], "go": [{"c": 3}]}}`,
+			"language go, tool 0: unknown field c\nlanguage sh, tool 0: unknown field a\nlanguage sh, tool 0: unknown field b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UnknownConfigFields(json.RawMessage(tt.settings))
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// DocumentsAffectedBy returns the open documents linted by tools that watch one of the changed files
+// under the root the tool runs in for that document, and those of projects whose configuration file changed.
+func (h *LangHandler) DocumentsAffectedBy(changes []types.FileEvent) []types.DocumentURI {
+	changed := make([]string, 0, len(changes))
+	projects := make([]string, 0)
+	for _, change := range changes {
+		fname, err := normalizedFilenameFromUri(change.URI)
+		if err != nil {
+			logs.Log.Logln(logs.Debug, err.Error())
+			continue
+		}
+		changed = append(changed, fname)
+		if path.Base(fname) == projectConfigName {
+			projects = append(projects, path.Dir(fname))
+		}
+	}
+
+	h.filesMu.RLock()
+	files := make([]fileRef, 0, len(h.files))
+	for _, f := range h.files {
+		files = append(files, *f)
+	}
+	h.filesMu.RUnlock()
+
+	affected := make([]types.DocumentURI, 0)
+	for _, f := range files {
+		if len(projects) > 0 && slices.Contains(projects, h.projectRoot(f.NormalizedFilename)) {
+			affected = append(affected, f.Uri)
+			continue
+		}
+		for _, cfg := range h.documentConfigs(f) {
+			if cfg.LintCommand == "" || len(cfg.LintWatchFiles) == 0 {
+				continue
+			}
+			rootPath := h.findRootPath(f.NormalizedFilename, cfg)
+			if slices.ContainsFunc(changed, func(fname string) bool { return isWatched(fname, rootPath, cfg.LintWatchFiles) }) {
+				affected = append(affected, f.Uri)
+				break
+			}
+		}
+	}
+	slices.Sort(affected)
+	return affected
+}
+
+// isWatched reports whether the file under rootPath matches one of the globs.
+func isWatched(fname, rootPath string, globs []string) bool {
+	if !inFolder(fname, rootPath) {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(rootPath), filepath.FromSlash(fname))
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, glob := range globs {
+		glob = strings.TrimPrefix(glob, "**/")
+		name := rel
+		if !strings.Contains(glob, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// watchPatterns returns the globs watched by the configured linters and project configuration files,
+// as patterns for the client. Roots can be anywhere in the workspace, so the patterns match at any depth.
+func (h *LangHandler) watchPatterns() []string {
+	patterns := []string{"**/" + projectConfigName}
+	for _, scope := range h.scopedConfigs() {
+		for _, cfgs := range scope.configs {
+			for _, cfg := range cfgs {
+				if cfg.LintCommand == "" {
+					continue
+				}
+				for _, glob := range cfg.LintWatchFiles {
+					patterns = append(patterns, "**/"+strings.TrimPrefix(glob, "**/"))
+				}
+			}
+		}
+	}
+	slices.Sort(patterns)
+	return slices.Compact(patterns)
+}
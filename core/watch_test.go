@@ -0,0 +1,60 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsWatched(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		globs    []string
+		expected bool
+	}{
+		{"name at root", "/root/.eslintrc.json", []string{".eslintrc*"}, true},
+		{"name in subdirectory", "/root/web/.eslintrc", []string{".eslintrc*"}, true},
+		{"double star prefix", "/root/web/.eslintrc", []string{"**/.eslintrc*"}, true},
+		{"path relative to root", "/root/config/lint.toml", []string{"config/*.toml"}, true},
+		{"path elsewhere", "/root/web/config/lint.toml", []string{"config/*.toml"}, false},
+		{"other name", "/root/pyproject.toml", []string{".eslintrc*", ".shellcheckrc"}, false},
+		{"outside root", "/other/.shellcheckrc", []string{".shellcheckrc"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isWatched(tt.file, "/root", tt.globs))
+		})
+	}
+}
+
+func TestDocumentsAffectedBy(t *testing.T) {
+	root := t.TempDir()
+	file := func(name string) (string, types.DocumentURI) {
+		fname := filepath.Join(root, name)
+		return filepath.ToSlash(fname), ParseLocalFileToURI(fname)
+	}
+	pyName, pyURI := file("app.py")
+	shName, shURI := file("run.sh")
+	_, rcURI := file(".shellcheckrc")
+	_, tomlURI := file("pyproject.toml")
+
+	h := &LangHandler{
+		RootPath: root,
+		configs: map[string][]types.Language{
+			"python": {{LintCommand: "flake8", LintWatchFiles: []string{"pyproject.toml", "setup.cfg"}}},
+			"sh":     {{LintCommand: "shellcheck", LintWatchFiles: []string{".shellcheckrc"}}, {FormatCommand: "shfmt"}},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			pyURI: {LanguageID: "python", NormalizedFilename: pyName, Uri: pyURI},
+			shURI: {LanguageID: "sh", NormalizedFilename: shName, Uri: shURI},
+		},
+	}
+
+	assert.Equal(t, []types.DocumentURI{shURI}, h.DocumentsAffectedBy([]types.FileEvent{{URI: rcURI, Type: types.FileChanged}}))
+	assert.Equal(t, []types.DocumentURI{pyURI, shURI}, h.DocumentsAffectedBy([]types.FileEvent{{URI: rcURI}, {URI: tomlURI}}))
+	assert.Empty(t, h.DocumentsAffectedBy([]types.FileEvent{{URI: pyURI}}))
+	assert.Equal(t, []string{"**/.flint-ls.yaml", "**/.shellcheckrc", "**/pyproject.toml", "**/setup.cfg"}, h.watchPatterns())
+}
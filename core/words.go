@@ -3,7 +3,6 @@ package core
 import (
 	"strings"
 	"unicode"
-	"unicode/utf16"
 
 	"github.com/konradmalik/flint-ls/types"
 )
@@ -15,26 +14,26 @@ const (
 	word
 )
 
-// lsp can now select encoding from the list that clients send that they support,
-// but utf16 is selected if the server does not send it and is required for backwards compatibility,
-// so we just support utf16
-func WordAtUtf16(text string, pos types.Position) []uint16 {
+// WordAt returns the word at the given position. pos.Character is expressed in the code units
+// of the negotiated position encoding.
+func WordAt(text string, pos types.Position, encoding types.PositionEncodingKind) string {
 	lines := strings.Split(text, "\n")
 	if pos.Line < 0 || pos.Line >= len(lines) {
-		return nil
+		return ""
 	}
-	chars := utf16.Encode([]rune(lines[pos.Line]))
-	if pos.Character < 0 || pos.Character > len(chars) {
-		return nil
+	line := lines[pos.Line]
+	if pos.Character < 0 || pos.Character > encodedLen(line, encoding) {
+		return ""
 	}
 
 	prevPos := 0
 	currPos := -1
 	prevCls := invalid
-	for i, char := range chars {
-		currCls := getRuneClass(rune(char))
+	units := 0
+	for i, r := range line {
+		currCls := getRuneClass(r)
 		if currCls != prevCls {
-			if i <= pos.Character {
+			if units <= pos.Character {
 				prevPos = i
 			} else {
 				currPos = i
@@ -42,11 +41,12 @@ func WordAtUtf16(text string, pos types.Position) []uint16 {
 			}
 		}
 		prevCls = currCls
+		units += runeUnits(r, encoding)
 	}
 	if currPos == -1 {
-		currPos = len(chars)
+		currPos = len(line)
 	}
-	return chars[prevPos:currPos]
+	return line[prevPos:currPos]
 }
 
 func getRuneClass(r rune) int {
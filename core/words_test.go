@@ -2,7 +2,6 @@ package core
 
 import (
 	"testing"
-	"unicode/utf16"
 
 	"github.com/konradmalik/flint-ls/types"
 	"github.com/stretchr/testify/assert"
@@ -44,8 +43,32 @@ func TestWordAt(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := string(utf16.Decode(WordAtUtf16(tt.text, tt.pos)))
+			got := WordAt(tt.text, tt.pos, types.UTF16)
 			assert.Equal(t, tt.expected, got)
 		})
 	}
 }
+
+func TestWordAtEncodings(t *testing.T) {
+	text := "😊 café latte"
+	tests := []struct {
+		name     string
+		encoding types.PositionEncodingKind
+		pos      types.Position
+		expected string
+	}{
+		{"utf-16 after emoji", types.UTF16, types.Position{Line: 0, Character: 3}, "café"},
+		{"utf-16 after accent", types.UTF16, types.Position{Line: 0, Character: 8}, "latte"},
+		{"utf-8 after emoji", types.UTF8, types.Position{Line: 0, Character: 5}, "café"},
+		{"utf-8 after accent", types.UTF8, types.Position{Line: 0, Character: 11}, "latte"},
+		{"utf-8 past end", types.UTF8, types.Position{Line: 0, Character: 18}, ""},
+		{"utf-32 after emoji", types.UTF32, types.Position{Line: 0, Character: 2}, "café"},
+		{"utf-32 after accent", types.UTF32, types.Position{Line: 0, Character: 7}, "latte"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, WordAt(text, tt.pos, tt.encoding))
+		})
+	}
+}
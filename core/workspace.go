@@ -0,0 +1,394 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// workspaceLint is a single run of a workspace linter in a root directory.
+type workspaceLint struct {
+	rootPath string
+	config   types.Language
+}
+
+func (w workspaceLint) key() string {
+	return lintToolKey(w.config) + "\x00" + w.rootPath
+}
+
+// workspaceRuns coalesces runs of workspace linters, so that each runs at most once at a time per root.
+// A trigger during a run queues a single rerun, which later triggers join, since the running linter
+// may have read files before they changed.
+type workspaceRuns struct {
+	mu      sync.Mutex
+	running map[string]*workspaceRun
+	queued  map[string]*workspaceRun
+	// roots of linters whose stored results no file changed since, by key
+	current map[string]string
+}
+
+type workspaceRun struct {
+	done chan struct{}
+	uris []types.DocumentURI
+	err  error
+}
+
+// do runs the linter with the given key, or joins the queued run, and waits for it to finish.
+// Runs do not stop when the caller gives up waiting, since other triggers may wait for them.
+// Joined reports whether the run was queued by another trigger, which reports its errors.
+func (r *workspaceRuns) do(ctx context.Context, key, rootPath string, lint func(ctx context.Context) ([]types.DocumentURI, error)) ([]types.DocumentURI, bool, error) {
+	r.mu.Lock()
+	if r.running == nil {
+		r.running = make(map[string]*workspaceRun)
+		r.queued = make(map[string]*workspaceRun)
+		r.current = make(map[string]string)
+	}
+	run, joined := r.queued[key]
+	if !joined {
+		run = &workspaceRun{done: make(chan struct{})}
+		var previous <-chan struct{}
+		if running, ok := r.running[key]; ok {
+			previous = running.done
+			r.queued[key] = run
+		} else {
+			r.running[key] = run
+		}
+		go r.start(context.WithoutCancel(ctx), key, rootPath, run, previous, lint)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-run.done:
+		return run.uris, joined, run.err
+	case <-ctx.Done():
+		return nil, joined, ctx.Err()
+	}
+}
+
+// start runs the linter once the previous run, if any, finishes.
+func (r *workspaceRuns) start(
+	ctx context.Context, key, rootPath string, run *workspaceRun, previous <-chan struct{}, lint func(ctx context.Context) ([]types.DocumentURI, error)) {
+	if previous != nil {
+		<-previous
+	}
+	r.mu.Lock()
+	if previous != nil {
+		delete(r.queued, key)
+		r.running[key] = run
+	}
+	// files changing from now on are not seen by this run
+	r.current[key] = rootPath
+	r.mu.Unlock()
+
+	run.uris, run.err = lint(ctx)
+
+	r.mu.Lock()
+	if r.running[key] == run {
+		delete(r.running, key)
+	}
+	if run.err != nil {
+		delete(r.current, key)
+	}
+	r.mu.Unlock()
+	close(run.done)
+}
+
+// latest reports whether stored results of the linter are current, that is the linter ran, or is running,
+// after files of its root last changed. Done is closed when the running and queued runs finish.
+func (r *workspaceRuns) latest(key string) (done <-chan struct{}, current bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.current[key]; !ok {
+		return nil, false
+	}
+	if run, ok := r.queued[key]; ok {
+		return run.done, true
+	}
+	if run, ok := r.running[key]; ok {
+		return run.done, true
+	}
+	return nil, true
+}
+
+// invalidate marks results of linters in the roots of the files as outdated,
+// and reports whether there were any.
+func (r *workspaceRuns) invalidate(fnames []string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var outdated bool
+	for key, rootPath := range r.current {
+		if slices.ContainsFunc(fnames, func(fname string) bool { return inFolder(fname, rootPath) }) {
+			delete(r.current, key)
+			outdated = true
+		}
+	}
+	return outdated
+}
+
+// WorkspaceFilesChanged drops workspace symbols and marks results of workspace linters as outdated
+// for the roots of the changed files, and drops changed project configurations and the roots found so far.
+// It reports whether results of any workspace linter are outdated.
+func (h *LangHandler) WorkspaceFilesChanged(uris []types.DocumentURI) bool {
+	fnames := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		fname, err := normalizedFilenameFromUri(uri)
+		if err != nil {
+			logs.Log.Logln(logs.Debug, err.Error())
+			continue
+		}
+		fnames = append(fnames, fname)
+	}
+	h.projects.changed(fnames)
+	h.workspaceSymbols.invalidate(fnames)
+	return h.workspaceRuns.invalidate(fnames)
+}
+
+// runWorkspaceLinter runs a workspace linter for its root, or waits for the run of it that is already queued,
+// and publishes results for every reported file.
+func (h *LangHandler) runWorkspaceLinter(ctx context.Context, w workspaceLint, diagnosticsOut chan<- types.PublishDiagnosticsParams) ([]types.DocumentURI, error) {
+	uris, joined, err := h.workspaceRuns.do(ctx, w.key(), w.rootPath, func(ctx context.Context) ([]types.DocumentURI, error) {
+		return h.storeWorkspaceLint(ctx, w)
+	})
+	if err != nil && joined && ctx.Err() == nil {
+		// reported by the trigger that queued the run
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil || diagnosticsOut == nil {
+		return uris, nil
+	}
+
+	h.diagnostics.mu.Lock()
+	defer h.diagnostics.mu.Unlock()
+	for _, uri := range uris {
+		var version int
+		if f, ok := h.getFile(uri); ok {
+			version = f.Version
+		}
+		diagnosticsOut <- types.PublishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: h.diagnostics.merged(uri),
+			Version:     version,
+		}
+	}
+	return uris, nil
+}
+
+// storeWorkspaceLint runs a workspace linter and stores its results for every reported file.
+// Files that were reported by the previous run of this linter, but are not reported anymore, are cleared.
+// It returns the files whose diagnostics were set.
+func (h *LangHandler) storeWorkspaceLint(ctx context.Context, w workspaceLint) ([]types.DocumentURI, error) {
+	results, err := h.lintWorkspace(ctx, w.rootPath, w.config)
+	if err != nil {
+		return nil, err
+	}
+
+	h.diagnostics.mu.Lock()
+	defer h.diagnostics.mu.Unlock()
+
+	uris := make([]types.DocumentURI, 0, len(results))
+	for uri := range results {
+		uris = append(uris, uri)
+	}
+	for _, uri := range h.diagnostics.setWorkspaceURIs(w.key(), lintToolKey(w.config), uris) {
+		if _, ok := results[uri]; !ok {
+			// no longer reported, clear
+			results[uri] = nil
+		}
+	}
+
+	affected := make([]types.DocumentURI, 0, len(results))
+	for uri, diagnostics := range results {
+		h.diagnostics.set(uri, lintToolKey(w.config), diagnostics)
+		affected = append(affected, uri)
+	}
+	slices.Sort(affected)
+	return affected, nil
+}
+
+// lintWorkspace runs a linter without an input file and splits its output by the reported files.
+func (h *LangHandler) lintWorkspace(ctx context.Context, rootPath string, config types.Language) (map[types.DocumentURI][]types.Diagnostic, error) {
+	cmdStr := replaceMagicStrings(config.LintCommand, "", rootPath)
+	cmd := buildExecCmd(ctx, cmdStr, rootPath, "", config, false)
+
+	done := traceTool(ctx, config, config.LintCommand, "")
+	lintOutput, err := runLintCommand(cmd, &config)
+	done(err)
+	logs.Log.Logln(logs.Info, cmdStr)
+	logs.Log.Logln(logs.Debug, string(lintOutput))
+	if err != nil {
+		return nil, err
+	}
+
+	efms, err := buildErrorformats(config.LintFormats)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[types.DocumentURI][]types.Diagnostic)
+	files := make(map[types.DocumentURI]fileRef)
+	efmsScanner := efms.NewScanner(bytes.NewReader(lintOutput))
+	for efmsScanner.Scan() {
+		entry := efmsScanner.Entry()
+		if !entry.Valid || entry.Filename == "" {
+			continue
+		}
+
+		fname := entry.Filename
+		if !filepath.IsAbs(fname) {
+			fname = filepath.Join(rootPath, fname)
+		}
+		uri := ParseLocalFileToURI(fname)
+
+		f, ok := files[uri]
+		if !ok {
+			f = h.workspaceFile(uri, fname)
+			files[uri] = f
+		}
+		results[uri] = append(results[uri], parseEfmEntryToDiagnostic(entry, config, f, h.positionEncoding))
+	}
+
+	return results, nil
+}
+
+// workspaceFile returns the open document, or reads it from disk so that columns can be resolved.
+func (h *LangHandler) workspaceFile(uri types.DocumentURI, fname string) fileRef {
+	if f, ok := h.getFile(uri); ok {
+		return f
+	}
+	text, err := os.ReadFile(fname)
+	if err != nil {
+		logs.Log.Logf(logs.Debug, "cannot read %s: %v", fname, err)
+	}
+	return fileRef{Text: string(text), NormalizedFilename: filepath.ToSlash(fname), Uri: uri}
+}
+
+// workspaceLints returns workspace linters for the roots of all open documents
+// and, for every configured language, for the workspace root.
+func (h *LangHandler) workspaceLints() []workspaceLint {
+	lints := make([]workspaceLint, 0)
+	seen := make(map[string]bool)
+	add := func(w workspaceLint) {
+		if w.rootPath == "" || seen[w.key()] {
+			return
+		}
+		seen[w.key()] = true
+		lints = append(lints, w)
+	}
+
+	h.filesMu.RLock()
+	files := make([]fileRef, 0, len(h.files))
+	for _, f := range h.files {
+		files = append(files, *f)
+	}
+	h.filesMu.RUnlock()
+
+	for _, f := range files {
+		for _, cfg := range h.documentConfigs(f) {
+			if cfg.LintCommand != "" && boolOrDefault(cfg.LintWorkspace, false) {
+				add(workspaceLint{rootPath: h.findRootPath(f.NormalizedFilename, cfg), config: cfg})
+			}
+		}
+	}
+	for _, scope := range h.scopedConfigs() {
+		for _, cfgs := range scope.configs {
+			for _, cfg := range cfgs {
+				if cfg.LintCommand != "" && boolOrDefault(cfg.LintWorkspace, false) {
+					add(workspaceLint{rootPath: scope.rootPath, config: cfg})
+				}
+			}
+		}
+	}
+	return lints
+}
+
+// WorkspaceDiagnostics answers a workspace/diagnostic request. Workspace linters run only when files
+// of their root changed since their last run, otherwise their stored results are reported.
+// Reports are returned for every file that has diagnostics from those linters, that had them,
+// and that the client has previous results for.
+func (h *LangHandler) WorkspaceDiagnostics(
+	ctx context.Context, previousResultIDs map[types.DocumentURI]string, errorsOut chan<- error) (types.WorkspaceDiagnosticReport, error) {
+	var mu sync.Mutex
+	affected := slices.Collect(maps.Keys(previousResultIDs))
+
+	var wg sync.WaitGroup
+	for _, w := range h.workspaceLints() {
+		wg.Go(func() {
+			var uris []types.DocumentURI
+			if done, current := h.workspaceRuns.latest(w.key()); current {
+				if done != nil {
+					select {
+					case <-done:
+					case <-ctx.Done():
+						return
+					}
+				}
+				uris = h.diagnostics.workspaceFiles(w.key())
+			} else {
+				var err error
+				uris, err = h.runWorkspaceLinter(ctx, w, nil)
+				if err != nil {
+					logs.Log.Logln(logs.Error, err.Error())
+					errorsOut <- err
+					return
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			affected = append(affected, uris...)
+		})
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return types.WorkspaceDiagnosticReport{}, err
+	}
+
+	slices.Sort(affected)
+	affected = slices.Compact(affected)
+
+	items := make([]types.WorkspaceDocumentDiagnosticReport, 0, len(affected))
+	for _, uri := range affected {
+		diagnostics := h.diagnostics.current(uri)
+		report := types.WorkspaceDocumentDiagnosticReport{
+			URI: uri,
+			DocumentDiagnosticReport: types.DocumentDiagnosticReport{
+				Kind:     types.DiagnosticReportFull,
+				ResultID: contentResultID(diagnostics),
+				Items:    &diagnostics,
+			},
+		}
+		if f, ok := h.getFile(uri); ok {
+			report.Version = &f.Version
+		}
+		if previousResultIDs[uri] == report.ResultID {
+			report.Kind = types.DiagnosticReportUnchanged
+			report.Items = nil
+		}
+		items = append(items, report)
+	}
+
+	return types.WorkspaceDiagnosticReport{Items: items}, nil
+}
+
+// contentResultID identifies a list of diagnostics by its content.
+func contentResultID(diagnostics []types.Diagnostic) string {
+	b, err := json.Marshal(diagnostics)
+	if err != nil {
+		return ""
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write(b)
+	return fmt.Sprintf("%x", hash.Sum64())
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWorkspaceTestHandler(t *testing.T) (*LangHandler, string, types.DocumentURI) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh specific cat")
+	}
+	root := t.TempDir()
+	file := filepath.Join(root, "a.txt")
+	uri := ParseLocalFileToURI(file)
+	assert.NoError(t, os.WriteFile(file, []byte("first line\nsecond line\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "b.txt"), []byte("other\n"), 0o644))
+
+	h := &LangHandler{
+		RootPath: root,
+		configs: map[string][]types.Language{
+			"txt": {
+				{
+					LintCommand:        "cat report",
+					LintFormats:        []string{"%f:%l:%c:%m"},
+					LintIgnoreExitCode: boolPtr(true),
+					LintWorkspace:      boolPtr(true),
+				},
+			},
+		},
+		files: map[types.DocumentURI]*fileRef{
+			uri: {
+				LanguageID:         "txt",
+				Text:               "first line\nsecond line\n",
+				NormalizedFilename: filepath.ToSlash(file),
+				Uri:                uri,
+				Version:            3,
+			},
+		},
+	}
+	return h, root, uri
+}
+
+func TestWorkspaceLinterPublishesPerFile(t *testing.T) {
+	h, root, uri := newWorkspaceTestHandler(t)
+	otherURI := ParseLocalFileToURI(filepath.Join(root, "b.txt"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "report"), []byte("a.txt:2:1:in a\nb.txt:1:1:in b\n"), 0o644))
+	pd, err := h.getAllPublishDiagnosticsParamsForUriWithEvent(t, uri, types.EventTypeChange)
+	assert.NoError(t, err)
+
+	published := make(map[types.DocumentURI]types.PublishDiagnosticsParams)
+	for _, p := range pd {
+		published[p.URI] = p
+	}
+	assert.Len(t, published, 2)
+	assert.Equal(t, 3, published[uri].Version)
+	assert.Equal(t, "in a", published[uri].Diagnostics[0].Message)
+	assert.Equal(t, types.Range{
+		Start: types.Position{Line: 1, Character: 0},
+		End:   types.Position{Line: 1, Character: 6},
+	}, published[uri].Diagnostics[0].Range)
+	assert.Equal(t, 0, published[otherURI].Version)
+	assert.Equal(t, "in b", published[otherURI].Diagnostics[0].Message)
+	assert.Equal(t, 5, published[otherURI].Diagnostics[0].Range.End.Character)
+
+	// b.txt is not reported anymore and gets cleared
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "report"), []byte("a.txt:1:1:still a\n"), 0o644))
+	pd, err = h.getAllPublishDiagnosticsParamsForUriWithEvent(t, uri, types.EventTypeChange)
+	assert.NoError(t, err)
+
+	published = make(map[types.DocumentURI]types.PublishDiagnosticsParams)
+	for _, p := range pd {
+		published[p.URI] = p
+	}
+	assert.Len(t, published[uri].Diagnostics, 1)
+	assert.Equal(t, "still a", published[uri].Diagnostics[0].Message)
+	assert.Contains(t, published, otherURI)
+	assert.Empty(t, published[otherURI].Diagnostics)
+}
+
+func TestWorkspaceDiagnostics(t *testing.T) {
+	h, root, uri := newWorkspaceTestHandler(t)
+	otherURI := ParseLocalFileToURI(filepath.Join(root, "b.txt"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "report"), []byte("a.txt:2:1:in a\nb.txt:1:1:in b\n"), 0o644))
+	report, err := h.WorkspaceDiagnostics(t.Context(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, report.Items, 2)
+
+	previous := make(map[types.DocumentURI]string)
+	for _, item := range report.Items {
+		assert.Equal(t, types.DiagnosticReportFull, item.Kind)
+		assert.Len(t, *item.Items, 1)
+		switch item.URI {
+		case uri:
+			assert.Equal(t, 3, *item.Version)
+		case otherURI:
+			assert.Nil(t, item.Version)
+		default:
+			t.Fatalf("unexpected uri %s", item.URI)
+		}
+		previous[item.URI] = item.ResultID
+	}
+
+	// stored results are reported until a file in the root changes
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "report"), []byte("a.txt:2:1:in a\n"), 0o644))
+	report, err = h.WorkspaceDiagnostics(t.Context(), previous, nil)
+	assert.NoError(t, err)
+	assert.Len(t, report.Items, 2)
+	for _, item := range report.Items {
+		assert.Equal(t, types.DiagnosticReportUnchanged, item.Kind)
+	}
+
+	assert.False(t, h.WorkspaceFilesChanged([]types.DocumentURI{ParseLocalFileToURI(filepath.Join(filepath.Dir(root), "report"))}))
+	assert.True(t, h.WorkspaceFilesChanged([]types.DocumentURI{ParseLocalFileToURI(filepath.Join(root, "report"))}))
+	report, err = h.WorkspaceDiagnostics(t.Context(), previous, nil)
+	assert.NoError(t, err)
+	assert.Len(t, report.Items, 2)
+	for _, item := range report.Items {
+		switch item.URI {
+		case uri:
+			assert.Equal(t, types.DiagnosticReportUnchanged, item.Kind)
+		case otherURI:
+			assert.Equal(t, types.DiagnosticReportFull, item.Kind)
+			assert.Empty(t, *item.Items)
+		}
+	}
+}
+
+func TestWorkspaceDiagnosticsOfClosedFile(t *testing.T) {
+	h, root, uri := newWorkspaceTestHandler(t)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "report"), []byte("a.txt:2:1:in a\n"), 0o644))
+	report, err := h.WorkspaceDiagnostics(t.Context(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, report.Items, 1)
+	assert.Len(t, *report.Items[0].Items, 1)
+
+	// stored results of the workspace linter outlive the document
+	assert.NoError(t, h.CloseFile(uri))
+	report, err = h.WorkspaceDiagnostics(t.Context(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, report.Items, 1)
+	assert.Equal(t, uri, report.Items[0].URI)
+	assert.Nil(t, report.Items[0].Version)
+	assert.Len(t, *report.Items[0].Items, 1)
+	assert.Equal(t, "in a", (*report.Items[0].Items)[0].Message)
+}
+
+func TestWorkspaceRunsCoalesced(t *testing.T) {
+	var r workspaceRuns
+	var runs atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	lint := func(context.Context) ([]types.DocumentURI, error) {
+		runs.Add(1)
+		started <- struct{}{}
+		<-release
+		return []types.DocumentURI{"file:///a"}, errors.New("failed")
+	}
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		uris, joined, err := r.do(t.Context(), "key", "/root", lint)
+		assert.Equal(t, []types.DocumentURI{"file:///a"}, uris)
+		assert.False(t, joined)
+		assert.Error(t, err)
+	})
+	<-started
+
+	// triggers during the run queue a single rerun, callers that give up waiting do not stop it
+	cancelled, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, joined, err := r.do(cancelled, "key", "/root", lint)
+	assert.False(t, joined)
+	assert.ErrorIs(t, err, context.Canceled)
+	for range 3 {
+		_, joined, _ := r.do(cancelled, "key", "/root", lint)
+		assert.True(t, joined)
+	}
+
+	close(release)
+	wg.Wait()
+	<-started
+	assert.Equal(t, int32(2), runs.Load())
+
+	// later triggers run the linter again
+	uris, joined, err := r.do(t.Context(), "key", "/root", lint)
+	assert.Equal(t, []types.DocumentURI{"file:///a"}, uris)
+	assert.False(t, joined)
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), runs.Load())
+}
@@ -0,0 +1,117 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/konradmalik/flint-ls/core"
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// configurationSection is the section of the client settings that holds the configuration.
+const configurationSection = "flint-ls"
+
+// pullConfiguration requests the configuration with workspace/configuration, globally and for each workspace folder,
+// and applies it once the client answers. Languages set for a folder are used for the documents in it.
+func (h *LspHandler) pullConfiguration(conn *jsonrpc2.Conn) {
+	folders := h.langHandler.WorkspaceFolders()
+	items := []types.ConfigurationItem{{Section: configurationSection}}
+	for _, folder := range folders {
+		items = append(items, types.ConfigurationItem{ScopeURI: core.ParseLocalFileToURI(folder), Section: configurationSection})
+	}
+
+	// the client answers only after the current message is handled
+	go func() {
+		h.configurationMu.Lock()
+		defer h.configurationMu.Unlock()
+		defer h.syncRegistrations(conn)
+
+		var settings []json.RawMessage
+		err := conn.Call(context.Background(), "workspace/configuration", &types.ConfigurationParams{Items: items}, &settings)
+		if err != nil {
+			logs.Log.Logf(logs.Error, "cannot pull configuration: %v", err)
+			return
+		}
+		if len(settings) != len(items) {
+			logs.Log.Logf(logs.Error, "expected %d configurations, got %d", len(items), len(settings))
+			return
+		}
+		configs := make([]*types.Config, len(settings))
+		for i, s := range settings {
+			if err := json.Unmarshal(s, &configs[i]); err != nil {
+				logs.Log.Logf(logs.Error, "cannot decode configuration: %v", err)
+				return
+			}
+		}
+
+		// settings with unknown keys are rejected
+		var errs []error
+		if err := core.UnknownConfigFields(settings[0]); err != nil {
+			errs = append(errs, err)
+		} else if configs[0] != nil {
+			errs = append(errs, h.updateConfiguration(configs[0]))
+		}
+		for i, folder := range folders {
+			if err := core.UnknownConfigFields(settings[i+1]); err != nil {
+				errs = append(errs, fmt.Errorf("folder %s: %w", folder, err))
+				continue
+			}
+			errs = append(errs, h.langHandler.UpdateFolderConfiguration(folder, configs[i+1]))
+		}
+		h.configurationErrors.set(errors.Join(errs...))
+		h.reportConfigurationErrors(conn)
+	}()
+}
+
+// configurationErrors keeps the problems found in the configuration, so that they are shown once and not every
+// time a tool runs.
+type configurationErrors struct {
+	mu sync.Mutex
+	// problems of the current configuration
+	current string
+	// problems last shown to the user
+	shown string
+}
+
+func (e *configurationErrors) set(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.current = ""
+	if err != nil {
+		e.current = err.Error()
+	}
+}
+
+// unreported returns the problems of the current configuration, unless they were already shown.
+func (e *configurationErrors) unreported() (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.current == e.shown {
+		return "", false
+	}
+	e.shown = e.current
+	return e.current, e.current != ""
+}
+
+// reportConfigurationErrors shows new problems of the configuration to the user with window/showMessage.
+// Problems found before initialization are shown once the client is initialized.
+func (h *LspHandler) reportConfigurationErrors(conn *jsonrpc2.Conn) {
+	if !h.initialized.Load() {
+		return
+	}
+	problems, ok := h.configurationErrors.unreported()
+	if !ok {
+		return
+	}
+	message := "invalid configuration:\n" + problems
+	logs.Log.Logln(logs.Error, message)
+	NewNotifier(conn).ShowMessage(context.Background(), types.MessError, message)
+}
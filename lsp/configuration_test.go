@@ -0,0 +1,204 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/konradmalik/flint-ls/core"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+func TestPullConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	h := NewHandler(core.NewHandler(core.NewConfig()))
+	defer h.Close()
+
+	folderLanguages := map[string][]types.Language{"go": {{FormatCommand: "gofmt"}}}
+	settings := make(chan []*types.Config, 2)
+	requests := make(chan types.ConfigurationParams, 2)
+	registered := make(chan string, 10)
+	client := connectClient(t, h, func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case "workspace/configuration":
+			var params types.ConfigurationParams
+			assert.NoError(t, json.Unmarshal(*req.Params, &params))
+			requests <- params
+			return <-settings, nil
+		case "client/registerCapability":
+			var params types.RegistrationParams
+			assert.NoError(t, json.Unmarshal(*req.Params, &params))
+			for _, r := range params.Registrations {
+				registered <- r.Method
+			}
+		}
+		return nil, nil
+	})
+	expectRegistered := func(method string) {
+		t.Helper()
+		select {
+		case m := <-registered:
+			assert.Equal(t, method, m)
+		case <-time.After(time.Second):
+			t.Fatalf("%v was not registered", method)
+		}
+	}
+
+	var result types.InitializeResult
+	assert.NoError(t, client.Call(t.Context(), "initialize", types.InitializeParams{
+		RootURI: core.ParseLocalFileToURI(dir),
+		Capabilities: types.ClientCapabilities{
+			Workspace: &types.WorkspaceClientCapabilities{Configuration: true},
+			TextDocument: &types.TextDocumentClientCapabilities{
+				Formatting:      &types.DynamicRegistrationCapabilities{DynamicRegistration: true},
+				RangeFormatting: &types.DynamicRegistrationCapabilities{DynamicRegistration: true},
+			},
+		},
+	}, &result))
+
+	settings <- []*types.Config{{FormatDebounce: time.Second}, {Languages: &folderLanguages}}
+	assert.NoError(t, client.Notify(t.Context(), "initialized", struct{}{}))
+	assert.Equal(t, types.ConfigurationParams{Items: []types.ConfigurationItem{
+		{Section: configurationSection},
+		{ScopeURI: core.ParseLocalFileToURI(dir), Section: configurationSection},
+	}}, <-requests)
+	expectRegistered("textDocument/formatting")
+
+	h.formatMu.Lock()
+	assert.Equal(t, time.Second, h.formatDebounce)
+	h.formatMu.Unlock()
+
+	// a bare notification pulls the configuration again
+	canRange := true
+	folderLanguages = map[string][]types.Language{"go": {{FormatCommand: "gofmt", FormatCanRange: &canRange}}}
+	settings <- []*types.Config{nil, {Languages: &folderLanguages}}
+	assert.NoError(t, client.Notify(t.Context(), "workspace/didChangeConfiguration", json.RawMessage(`{"settings":null}`)))
+	<-requests
+	expectRegistered("textDocument/rangeFormatting")
+
+	// wait for the registration to receive the response, the connection must not close while it waits
+	h.capabilities.mu.Lock()
+	h.capabilities.mu.Unlock()
+}
+
+func TestAddedWorkspaceFolderPullsConfiguration(t *testing.T) {
+	base := t.TempDir()
+	first := core.ParseLocalFileToURI(filepath.Join(base, "first"))
+	second := core.ParseLocalFileToURI(filepath.Join(base, "second"))
+	h := NewHandler(core.NewHandler(core.NewConfig()))
+	defer h.Close()
+
+	requests := make(chan types.ConfigurationParams, 2)
+	client := connectClient(t, h, func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		if req.Method == "workspace/configuration" {
+			var params types.ConfigurationParams
+			assert.NoError(t, json.Unmarshal(*req.Params, &params))
+			requests <- params
+			return make([]*types.Config, len(params.Items)), nil
+		}
+		return nil, nil
+	})
+	scopes := func() []types.DocumentURI {
+		t.Helper()
+		select {
+		case params := <-requests:
+			uris := make([]types.DocumentURI, 0)
+			for _, item := range params.Items {
+				uris = append(uris, item.ScopeURI)
+			}
+			return uris
+		case <-time.After(time.Second):
+			t.Fatal("configuration was not pulled")
+			return nil
+		}
+	}
+
+	var result types.InitializeResult
+	assert.NoError(t, client.Call(t.Context(), "initialize", types.InitializeParams{
+		WorkspaceFolders: []types.WorkspaceFolder{{URI: first}},
+		Capabilities:     types.ClientCapabilities{Workspace: &types.WorkspaceClientCapabilities{Configuration: true}},
+	}, &result))
+	assert.True(t, result.Capabilities.Workspace.WorkspaceFolders.ChangeNotifications)
+
+	assert.NoError(t, client.Notify(t.Context(), "initialized", struct{}{}))
+	assert.Equal(t, []types.DocumentURI{"", first}, scopes())
+
+	assert.NoError(t, client.Notify(t.Context(), "workspace/didChangeWorkspaceFolders", types.DidChangeWorkspaceFoldersParams{
+		Event: types.WorkspaceFoldersChangeEvent{Added: []types.WorkspaceFolder{{URI: second}}},
+	}))
+	assert.Equal(t, []types.DocumentURI{"", first, second}, scopes())
+
+	// wait for the pull to apply the answer, the connection must not close while it waits
+	h.configurationMu.Lock()
+	h.configurationMu.Unlock()
+}
+
+func TestConfigurationErrorsShownOnce(t *testing.T) {
+	languages := map[string][]types.Language{"sh": {{Tool: "shellcheck"}}}
+	config := core.NewConfig()
+	config.Languages = &languages
+	h := NewHandler(core.NewHandler(config))
+	h.UpdateConfiguration(config)
+	defer h.Close()
+
+	messages := make(chan types.ShowMessageParams, 10)
+	client := connectClient(t, h, func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		if req.Method == "window/showMessage" {
+			var params types.ShowMessageParams
+			assert.NoError(t, json.Unmarshal(*req.Params, &params))
+			messages <- params
+		}
+		return nil, nil
+	})
+	expectMessage := func(expected string) {
+		t.Helper()
+		select {
+		case m := <-messages:
+			assert.Equal(t, types.ShowMessageParams{Type: types.MessError, Message: expected}, m)
+		case <-time.After(time.Second):
+			t.Fatalf("%q was not shown", expected)
+		}
+	}
+	expectNoMessage := func() {
+		t.Helper()
+		select {
+		case m := <-messages:
+			t.Fatalf("unexpected message %q", m.Message)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	changeSettings := func(settings string) {
+		t.Helper()
+		assert.NoError(t, client.Notify(t.Context(), "workspace/didChangeConfiguration", json.RawMessage(`{"settings":`+settings+`}`)))
+	}
+
+	var result types.InitializeResult
+	assert.NoError(t, client.Call(t.Context(), "initialize", types.InitializeParams{}, &result))
+	// problems of the configuration file are shown once the client is initialized
+	expectNoMessage()
+	assert.NoError(t, client.Notify(t.Context(), "initialized", struct{}{}))
+	expectMessage("invalid configuration:\nlanguage sh, tool 0: unknown tool shellcheck")
+
+	bad := `{"lintDebounce": 1, "languages": {"sh": [{"preset": "shellcheck", "lintComand": "shellcheck -"}], "yaml": [{"lintCommand": "yamllint ${INPUT"}]}}`
+	changeSettings(bad)
+	expectMessage("invalid configuration:\nlanguage sh, tool 0: unknown field lintComand")
+
+	// the same problems are not shown again
+	changeSettings(bad)
+	expectNoMessage()
+
+	// settings with unknown keys are rejected, the problems of valid ones are shown
+	changeSettings(`{"languages": {"sh": [{"preset": "shellcheck"}], "yaml": [{"lintCommand": "yamllint ${INPUT"}]}}`)
+	expectMessage("invalid configuration:\nlanguage yaml, tool 0: lintCommand: placeholder without closing }")
+
+	changeSettings(`{"languages": {"sh": [{"preset": "shellcheck"}]}}`)
+	expectNoMessage()
+
+	changeSettings(bad)
+	expectMessage("invalid configuration:\nlanguage sh, tool 0: unknown field lintComand")
+}
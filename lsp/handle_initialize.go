@@ -19,5 +19,31 @@ func (h *LspHandler) HandleInitialize(_ context.Context, _ *jsonrpc2.Conn, req *
 		return types.InitializeResult{}, err
 	}
 
-	return h.langHandler.Initialize(params)
+	h.pullDiagnostics = params.Capabilities.TextDocument != nil && params.Capabilities.TextDocument.Diagnostic != nil
+	h.workDoneProgress = params.Capabilities.Window != nil && params.Capabilities.Window.WorkDoneProgress
+	h.capabilities.dynamic = dynamicMethods(params.Capabilities)
+	h.configurationPull = params.Capabilities.Workspace != nil && params.Capabilities.Workspace.Configuration
+	h.diagnosticRefresh = params.Capabilities.Workspace != nil && params.Capabilities.Workspace.Diagnostics != nil &&
+		params.Capabilities.Workspace.Diagnostics.RefreshSupport
+
+	result, err = h.langHandler.Initialize(params)
+	if err != nil {
+		return types.InitializeResult{}, err
+	}
+	// tools are usually configured after initialization, capabilities are then registered per language
+	result.Capabilities = withoutDynamicCapabilities(result.Capabilities, h.capabilities.dynamic)
+	return result, nil
+}
+
+func (h *LspHandler) HandleInitialized(_ context.Context, conn *jsonrpc2.Conn, _ *jsonrpc2.Request) (result any, err error) {
+	h.initialized.Store(true)
+	// problems of the configuration file
+	h.reportConfigurationErrors(conn)
+	if h.configurationPull {
+		// registrations are synced once the configuration arrives
+		h.pullConfiguration(conn)
+		return nil, nil
+	}
+	h.syncRegistrations(conn)
+	return nil, nil
 }
@@ -0,0 +1,54 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/konradmalik/flint-ls/core"
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#errorCodes
+const codeContentModified int64 = -32801
+
+func (h *LspHandler) HandleTextDocumentDiagnostic(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params types.DocumentDiagnosticParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	uri := params.TextDocument.URI
+	notifier := NewNotifier(conn)
+	lintErrors := make(chan error)
+	defer close(lintErrors)
+	go func() {
+		for e := range lintErrors {
+			logs.Log.Logln(logs.Error, e.Error())
+			notifier.LogMessage(ctx, types.MessError, e.Error())
+		}
+	}()
+
+	ctx, end := h.withProgress(ctx, conn, "Linting")
+	defer end()
+
+	eventType := h.pullEvents.take(uri)
+	report, err := h.langHandler.PullDiagnostics(ctx, uri, eventType, params.PreviousResultID, lintErrors)
+	if err != nil {
+		// the event was not handled, keep it for the next request
+		h.pullEvents.record(uri, eventType)
+	}
+	if errors.Is(err, core.ErrDocumentChanged) {
+		return nil, &jsonrpc2.Error{Code: codeContentModified, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -18,10 +18,8 @@ func (h *LspHandler) HandleTextDocumentDidChange(_ context.Context, conn *jsonrp
 		return nil, err
 	}
 
-	for _, change := range params.ContentChanges {
-		if err := h.langHandler.UpdateFile(params.TextDocument.URI, change.Text, &params.TextDocument.Version); err != nil {
-			return nil, err
-		}
+	if err := h.langHandler.ApplyChanges(params.TextDocument.URI, params.ContentChanges, params.TextDocument.Version); err != nil {
+		return nil, err
 	}
 
 	notifier := NewNotifier(conn)
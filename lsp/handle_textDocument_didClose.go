@@ -18,6 +18,8 @@ func (h *LspHandler) HandleTextDocumentDidClose(_ context.Context, _ *jsonrpc2.C
 		return nil, err
 	}
 
+	h.lintScheduler.Cancel(params.TextDocument.URI)
+	h.pullEvents.forget(params.TextDocument.URI)
 	if err := h.langHandler.CloseFile(params.TextDocument.URI); err != nil {
 		return nil, err
 	}
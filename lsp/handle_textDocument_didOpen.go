@@ -24,6 +24,8 @@ func (h *LspHandler) HandleTextDocumentDidOpen(_ context.Context, conn *jsonrpc2
 
 	notifier := NewNotifier(conn)
 	h.ScheduleLinting(*notifier, params.TextDocument.URI, types.EventTypeOpen)
+	// the document may be in a project with its own configuration file
+	h.syncRegistrations(conn)
 
 	return nil, nil
 }
@@ -29,6 +29,8 @@ func (h *LspHandler) HandleTextDocumentDidSave(_ context.Context, conn *jsonrpc2
 		return nil, err
 	}
 
+	h.langHandler.WorkspaceFilesChanged([]types.DocumentURI{params.TextDocument.URI})
+
 	notifier := NewNotifier(conn)
 	h.ScheduleLinting(*notifier, params.TextDocument.URI, event)
 
@@ -0,0 +1,22 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *LspHandler) HandleTextDocumentDocumentSymbol(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params types.DocumentSymbolParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	return h.langHandler.DocumentSymbols(ctx, params.TextDocument.URI)
+}
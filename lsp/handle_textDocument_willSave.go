@@ -0,0 +1,55 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const defaultFormatOnSaveTimeout = time.Second
+
+func (h *LspHandler) HandleTextDocumentWillSave(_ context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (result any, err error) {
+	return nil, nil
+}
+
+// HandleTextDocumentWillSaveWaitUntil formats the document with formatters that opted in to formatting on save.
+// The save is not debounced, and when formatting fails or exceeds its time budget, no edits are returned
+// so that the document is still saved.
+func (h *LspHandler) HandleTextDocumentWillSaveWaitUntil(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params types.WillSaveTextDocumentParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	h.formatMu.Lock()
+	timeout := h.formatOnSaveTimeout
+	h.formatMu.Unlock()
+	if timeout <= 0 {
+		timeout = defaultFormatOnSaveTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	edits, err := h.langHandler.RunSaveFormatters(ctx, params.TextDocument.URI)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		logs.Log.Logf(logs.Warn, "format on save exceeded %v, saving unformatted: %v", timeout, params.TextDocument.URI)
+		return []types.TextEdit{}, nil
+	}
+	if err != nil {
+		logs.Log.Logln(logs.Error, err.Error())
+		return []types.TextEdit{}, nil
+	}
+	if edits == nil {
+		return []types.TextEdit{}, nil
+	}
+	return edits, nil
+}
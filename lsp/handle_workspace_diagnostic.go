@@ -0,0 +1,41 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *LspHandler) HandleWorkspaceDiagnostic(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params types.WorkspaceDiagnosticParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	previousResultIDs := make(map[types.DocumentURI]string, len(params.PreviousResultIDs))
+	for _, p := range params.PreviousResultIDs {
+		previousResultIDs[p.URI] = p.Value
+	}
+
+	notifier := NewNotifier(conn)
+	lintErrors := make(chan error)
+	defer close(lintErrors)
+	go func() {
+		for e := range lintErrors {
+			logs.Log.Logln(logs.Error, e.Error())
+			notifier.LogMessage(ctx, types.MessError, e.Error())
+		}
+	}()
+
+	ctx, end := h.withProgress(ctx, conn, "Linting workspace")
+	defer end()
+
+	return h.langHandler.WorkspaceDiagnostics(ctx, previousResultIDs, lintErrors)
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/konradmalik/flint-ls/core"
 	"github.com/konradmalik/flint-ls/types"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
-func (h *LspHandler) HandleWorkspaceDidChangeConfiguration(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+func (h *LspHandler) HandleWorkspaceDidChangeConfiguration(_ context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
 	}
@@ -18,6 +19,26 @@ func (h *LspHandler) HandleWorkspaceDidChangeConfiguration(_ context.Context, _
 		return nil, err
 	}
 
-	h.UpdateConfiguration(&params.Settings)
+	// a notification without settings tells that they changed and should be pulled
+	if h.configurationPull && params.Settings == (types.Config{}) {
+		h.pullConfiguration(conn)
+		return nil, nil
+	}
+
+	var raw struct {
+		Settings json.RawMessage `json:"settings"`
+	}
+	if err := json.Unmarshal(*req.Params, &raw); err != nil {
+		return nil, err
+	}
+
+	// settings with unknown keys are rejected, like configuration files with them
+	if err := core.UnknownConfigFields(raw.Settings); err != nil {
+		h.configurationErrors.set(err)
+	} else {
+		h.configurationErrors.set(h.updateConfiguration(&params.Settings))
+	}
+	h.reportConfigurationErrors(conn)
+	h.syncRegistrations(conn)
 	return nil, nil
 }
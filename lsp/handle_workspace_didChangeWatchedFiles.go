@@ -0,0 +1,52 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// HandleWorkspaceDidChangeWatchedFiles lints open documents again when files watched by their linters change,
+// as if they were saved. Clients that pull diagnostics are asked to pull them again.
+// Workspace symbols and workspace linters of the roots of changed files run again on the next request.
+func (h *LspHandler) HandleWorkspaceDidChangeWatchedFiles(_ context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params types.DidChangeWatchedFilesParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	uris := make([]types.DocumentURI, 0, len(params.Changes))
+	for _, change := range params.Changes {
+		uris = append(uris, change.URI)
+	}
+	outdated := h.langHandler.WorkspaceFilesChanged(uris)
+
+	// project configuration files may have changed
+	h.syncRegistrations(conn)
+
+	affected := h.langHandler.DocumentsAffectedBy(params.Changes)
+	if len(affected) == 0 && !outdated {
+		return nil, nil
+	}
+
+	notifier := NewNotifier(conn)
+	for _, uri := range affected {
+		h.ScheduleLinting(*notifier, uri, types.EventTypeSave)
+	}
+	if h.pullDiagnostics && h.diagnosticRefresh {
+		// the client answers only after the current message is handled
+		go func() {
+			if err := conn.Call(context.Background(), "workspace/diagnostic/refresh", nil, nil); err != nil {
+				logs.Log.Logf(logs.Debug, "cannot refresh diagnostics: %v", err)
+			}
+		}()
+	}
+	return nil, nil
+}
@@ -0,0 +1,31 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *LspHandler) HandleWorkspaceDidChangeWorkspaceFolders(_ context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params types.DidChangeWorkspaceFoldersParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	if err := h.langHandler.UpdateWorkspaceFolders(params.Event); err != nil {
+		return nil, err
+	}
+	if h.configurationPull {
+		// added folders may have their own settings
+		h.pullConfiguration(conn)
+		return nil, nil
+	}
+	h.syncRegistrations(conn)
+	return nil, nil
+}
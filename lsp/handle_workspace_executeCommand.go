@@ -0,0 +1,52 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *LspHandler) HandleWorkspaceExecuteCommand(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params types.ExecuteCommandParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	var uri types.DocumentURI
+	if len(params.Arguments) > 0 {
+		s, ok := params.Arguments[0].(string)
+		if !ok {
+			return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "first argument must be a document uri"}
+		}
+		uri = types.DocumentURI(s)
+	}
+
+	applyEdit, message, err := h.langHandler.ExecuteCommand(ctx, params.Command, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier := NewNotifier(conn)
+	if message != "" {
+		notifier.ShowMessage(ctx, types.MessInfo, message)
+	}
+	if applyEdit != nil {
+		// executeCommand is handled concurrently with other requests, so waiting for the response is safe
+		var result types.ApplyWorkspaceEditResult
+		if err := conn.Call(ctx, "workspace/applyEdit", applyEdit, &result); err != nil {
+			return nil, err
+		}
+		if !result.Applied {
+			logs.Log.Logf(logs.Warn, "edit of %v not applied: %v", params.Command, result.FailureReason)
+		}
+	}
+
+	return nil, nil
+}
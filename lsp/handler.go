@@ -2,8 +2,10 @@ package lsp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sourcegraph/jsonrpc2"
@@ -14,28 +16,64 @@ import (
 )
 
 type LspHandler struct {
-	langHandler    *core.LangHandler
-	formatMu       sync.Mutex
-	lintMu         sync.Mutex
-	lintTimer      *time.Timer
-	lintDebounce   time.Duration
-	formatTimer    *time.Timer
-	formatDebounce time.Duration
+	langHandler *core.LangHandler
+	// guards formatTimer and the format settings
+	formatMu      sync.Mutex
+	lintScheduler *lintScheduler
+	// set when the client pulls diagnostics, linting is then driven by its requests
+	pullDiagnostics bool
+	// set when the client can be asked to pull diagnostics again
+	diagnosticRefresh bool
+	pullEvents        *pendingEvents
+	formatTimer       *time.Timer
+	formatDebounce    time.Duration
+	// time budget of willSaveWaitUntil
+	formatOnSaveTimeout time.Duration
+	requests            *requestRegistry
+	// set when the client supports server initiated progress
+	workDoneProgress bool
+	// running progress tokens mapped to the cancel functions of their work
+	progress *requestRegistry
+	// set once the client sent the initialized notification
+	initialized  atomic.Bool
+	capabilities *capabilityRegistry
+	// set when the client provides settings with workspace/configuration
+	configurationPull bool
+	// serializes configuration pulls, so that answers are applied in order
+	configurationMu     sync.Mutex
+	configurationErrors configurationErrors
 }
 
 func NewHandler(langHandler *core.LangHandler) *LspHandler {
-	return &LspHandler{langHandler: langHandler}
+	return &LspHandler{
+		langHandler:   langHandler,
+		lintScheduler: newLintScheduler(),
+		pullEvents:    newPendingEvents(),
+		requests:      newRequestRegistry(),
+		progress:      newRequestRegistry(),
+		capabilities:  newCapabilityRegistry(),
+	}
 }
 
+// UpdateConfiguration applies the settings. Problems found in them are shown to the user once the client is initialized.
 func (h *LspHandler) UpdateConfiguration(config *types.Config) {
+	h.configurationErrors.set(h.updateConfiguration(config))
+}
+
+func (h *LspHandler) updateConfiguration(config *types.Config) error {
 	if config.LintDebounce > 0 {
-		h.lintDebounce = config.LintDebounce
+		h.lintScheduler.SetDebounce(config.LintDebounce)
 	}
+	h.formatMu.Lock()
 	if config.FormatDebounce > 0 {
 		h.formatDebounce = config.FormatDebounce
 	}
+	if config.FormatOnSaveTimeout > 0 {
+		h.formatOnSaveTimeout = config.FormatOnSaveTimeout
+	}
+	h.formatMu.Unlock()
 
-	h.langHandler.UpdateConfiguration(config)
+	return h.langHandler.UpdateConfiguration(config)
 }
 
 func (h *LspHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
@@ -43,7 +81,11 @@ func (h *LspHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonr
 	case "initialize":
 		return h.HandleInitialize(ctx, conn, req)
 	case "initialized":
-		return
+		return h.HandleInitialized(ctx, conn, req)
+	case "$/cancelRequest":
+		return h.HandleCancelRequest(ctx, conn, req)
+	case "window/workDoneProgress/cancel":
+		return h.HandleWorkDoneProgressCancel(ctx, conn, req)
 	case "shutdown":
 		return h.HandleShutdown(ctx, conn, req)
 	case "textDocument/didOpen":
@@ -52,91 +94,111 @@ func (h *LspHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonr
 		return h.HandleTextDocumentDidChange(ctx, conn, req)
 	case "textDocument/didSave":
 		return h.HandleTextDocumentDidSave(ctx, conn, req)
+	case "textDocument/willSave":
+		return h.HandleTextDocumentWillSave(ctx, conn, req)
+	case "textDocument/willSaveWaitUntil":
+		return h.HandleTextDocumentWillSaveWaitUntil(ctx, conn, req)
 	case "textDocument/didClose":
 		return h.HandleTextDocumentDidClose(ctx, conn, req)
 	case "textDocument/formatting":
 		return h.HandleTextDocumentFormatting(ctx, conn, req)
 	case "textDocument/rangeFormatting":
 		return h.HandleTextDocumentRangeFormatting(ctx, conn, req)
+	case "textDocument/hover":
+		return h.HandleTextDocumentHover(ctx, conn, req)
+	case "textDocument/completion":
+		return h.HandleTextDocumentCompletion(ctx, conn, req)
+	case "textDocument/documentSymbol":
+		return h.HandleTextDocumentDocumentSymbol(ctx, conn, req)
+	case "textDocument/codeAction":
+		return h.HandleTextDocumentCodeAction(ctx, conn, req)
+	case "textDocument/diagnostic":
+		return h.HandleTextDocumentDiagnostic(ctx, conn, req)
+	case "workspace/diagnostic":
+		return h.HandleWorkspaceDiagnostic(ctx, conn, req)
+	case "workspace/symbol":
+		return h.HandleWorkspaceSymbol(ctx, conn, req)
+	case "workspace/executeCommand":
+		return h.HandleWorkspaceExecuteCommand(ctx, conn, req)
 	case "workspace/didChangeConfiguration":
 		return h.HandleWorkspaceDidChangeConfiguration(ctx, conn, req)
+	case "workspace/didChangeWorkspaceFolders":
+		return h.HandleWorkspaceDidChangeWorkspaceFolders(ctx, conn, req)
+	case "workspace/didChangeWatchedFiles":
+		return h.HandleWorkspaceDidChangeWatchedFiles(ctx, conn, req)
 	}
 
 	return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
 }
 
-func (h *LspHandler) Formatting(ctx context.Context, uri types.DocumentURI, rng *types.Range, opt types.FormattingOptions) ([]types.TextEdit, error) {
+func (h *LspHandler) Formatting(ctx context.Context, conn *jsonrpc2.Conn, uri types.DocumentURI, rng *types.Range, opt types.FormattingOptions) ([]types.TextEdit, error) {
+	// formatting requests are handled concurrently
+	h.formatMu.Lock()
 	if h.formatTimer != nil {
+		h.formatMu.Unlock()
 		logs.Log.Logf(logs.Debug, "format debounced: %v", h.formatDebounce)
 		return []types.TextEdit{}, nil
 	}
-
-	h.formatMu.Lock()
 	h.formatTimer = time.AfterFunc(h.formatDebounce, func() {
 		h.formatMu.Lock()
 		h.formatTimer = nil
 		h.formatMu.Unlock()
 	})
 	h.formatMu.Unlock()
+
+	ctx, end := h.withProgress(ctx, conn, "Formatting")
+	defer end()
 	return h.langHandler.RunAllFormatters(ctx, uri, rng, opt)
 }
 
-var running = make(map[types.DocumentURI]context.CancelFunc)
-
 func (h *LspHandler) ScheduleLinting(notifier LspNotifier, uri types.DocumentURI, eventType types.EventType) {
-	if h.lintTimer != nil {
-		h.lintTimer.Reset(h.lintDebounce)
-		logs.Log.Logf(logs.Debug, "lint debounced: %v", h.formatDebounce)
+	if h.pullDiagnostics {
+		h.pullEvents.record(uri, eventType)
 		return
 	}
-	h.lintMu.Lock()
-	h.lintTimer = time.AfterFunc(h.lintDebounce, func() {
-		h.lintTimer = nil
-
-		h.lintMu.Lock()
-		cancel, ok := running[uri]
-		if ok {
-			cancel()
-		}
 
-		ctx, cancel := context.WithCancel(context.Background())
-		running[uri] = cancel
-		h.lintMu.Unlock()
-
-		func() {
-			diagnostics := make(chan types.PublishDiagnosticsParams)
-			errors := make(chan error)
-			defer close(diagnostics)
-			defer close(errors)
-
-			go func() {
-				for d := range diagnostics {
-					notifier.PublishDiagnostics(ctx, d)
-				}
-			}()
-
-			go func() {
-				for e := range errors {
-					logs.Log.Logln(logs.Error, e.Error())
-					notifier.LogMessage(ctx, types.MessError, e.Error())
-				}
-			}()
-
-			err := h.langHandler.RunAllLinters(ctx, uri, eventType, diagnostics, errors)
-			if err != nil {
-				logs.Log.Logln(logs.Error, err.Error())
-				notifier.LogMessage(ctx, types.MessError, err.Error())
+	h.lintScheduler.Schedule(uri, eventType, func(ctx context.Context, eventType types.EventType) {
+		ctx, end := h.withProgress(ctx, notifier.conn, "Linting")
+		defer end()
+
+		diagnostics := make(chan types.PublishDiagnosticsParams)
+		lintErrors := make(chan error)
+		defer close(diagnostics)
+		defer close(lintErrors)
+
+		go func() {
+			for d := range diagnostics {
+				notifier.PublishDiagnostics(ctx, d)
+			}
+		}()
+
+		go func() {
+			for e := range lintErrors {
+				logs.Log.Logln(logs.Error, e.Error())
+				notifier.LogMessage(ctx, types.MessError, e.Error())
 			}
 		}()
+
+		err := h.langHandler.RunAllLinters(ctx, uri, eventType, diagnostics, lintErrors)
+		if errors.Is(err, core.ErrDocumentChanged) {
+			logs.Log.Logln(logs.Debug, err.Error())
+			h.ScheduleLinting(notifier, uri, eventType)
+			return
+		}
+		if err != nil {
+			logs.Log.Logln(logs.Error, err.Error())
+			notifier.LogMessage(ctx, types.MessError, err.Error())
+		}
 	})
-	h.lintMu.Unlock()
 }
 
 func (h *LspHandler) Close() {
+	h.formatMu.Lock()
 	if h.formatTimer != nil {
 		h.formatTimer.Stop()
 	}
-	if h.lintTimer != nil {
-		h.lintTimer.Stop()
-	}
+	h.formatMu.Unlock()
+	h.lintScheduler.Stop()
+	h.requests.cancelAll()
+	h.progress.cancelAll()
 }
@@ -26,6 +26,16 @@ func (n *LspNotifier) LogMessage(ctx context.Context, typ types.MessageType, mes
 		})
 }
 
+func (n *LspNotifier) ShowMessage(ctx context.Context, typ types.MessageType, message string) {
+	_ = n.conn.Notify(
+		ctx,
+		"window/showMessage",
+		&types.ShowMessageParams{
+			Type:    typ,
+			Message: message,
+		})
+}
+
 func (n *LspNotifier) PublishDiagnostics(ctx context.Context, params types.PublishDiagnosticsParams) {
 	_ = n.conn.Notify(
 		ctx,
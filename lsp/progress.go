@@ -0,0 +1,146 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/konradmalik/flint-ls/core"
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// progressDelay is how long tools run before progress is shown, so that fast tools do not flash in the editor.
+const progressDelay = 500 * time.Millisecond
+
+var progressTokens atomic.Uint64
+
+// workDoneProgress reports the tools of a run as server initiated progress.
+type workDoneProgress struct {
+	conn  *jsonrpc2.Conn
+	token types.ProgressToken
+	title string
+
+	mu    sync.Mutex
+	timer *time.Timer
+	// set once the client created the token, progress is only sent after that
+	begun bool
+	ended bool
+	// "tool: file" of the tools that are running
+	running []string
+}
+
+// withProgress shows the tools run with the returned context as progress, if the client supports it.
+// Cancelling the progress in the client cancels the returned context. end must be called once the work is done.
+func (h *LspHandler) withProgress(ctx context.Context, conn *jsonrpc2.Conn, title string) (context.Context, func()) {
+	if !h.workDoneProgress || conn == nil {
+		return ctx, func() {}
+	}
+
+	p := &workDoneProgress{
+		conn:  conn,
+		token: types.ProgressToken{Str: fmt.Sprintf("flint-ls/%d", progressTokens.Add(1)), IsString: true},
+		title: title,
+	}
+	workCtx, cancel := context.WithCancel(ctx)
+	h.progress.add(p.token, cancel)
+	p.timer = time.AfterFunc(progressDelay, func() { p.begin(workCtx) })
+
+	trace := &core.ToolTrace{
+		ToolStart: func(tool, filename string) {
+			p.update(func(running []string) []string { return append(running, toolMessage(tool, filename)) })
+		},
+		ToolDone: func(tool, filename string, _ error) {
+			p.update(func(running []string) []string {
+				if i := slices.Index(running, toolMessage(tool, filename)); i >= 0 {
+					return slices.Delete(running, i, i+1)
+				}
+				return running
+			})
+		},
+	}
+	end := func() {
+		h.progress.remove(p.token)
+		cancel()
+		p.end()
+	}
+	return core.WithToolTrace(workCtx, trace), end
+}
+
+func toolMessage(tool, filename string) string {
+	if filename == "" {
+		return tool
+	}
+	return tool + ": " + filepath.Base(filename)
+}
+
+func (p *workDoneProgress) begin(ctx context.Context) {
+	err := p.conn.Call(ctx, "window/workDoneProgress/create", &types.WorkDoneProgressCreateParams{Token: p.token}, nil)
+	if err != nil {
+		logs.Log.Logf(logs.Debug, "cannot create progress %v: %v", p.token, err)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ended {
+		return
+	}
+	p.begun = true
+	p.notify(types.WorkDoneProgressBegin{Kind: "begin", Title: p.title, Cancellable: true, Message: p.message()})
+}
+
+func (p *workDoneProgress) update(change func(running []string) []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = change(p.running)
+	// with nothing running the work is about to end, an empty report would only blank the message
+	if p.begun && !p.ended && len(p.running) > 0 {
+		p.notify(types.WorkDoneProgressReport{Kind: "report", Message: p.message()})
+	}
+}
+
+func (p *workDoneProgress) end() {
+	p.timer.Stop()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.begun && !p.ended {
+		p.notify(types.WorkDoneProgressEnd{Kind: "end"})
+	}
+	p.ended = true
+}
+
+// notify expects the caller to hold mu.
+func (p *workDoneProgress) notify(value any) {
+	_ = p.conn.Notify(context.Background(), "$/progress", &types.ProgressParams{Token: p.token, Value: value})
+}
+
+// message expects the caller to hold mu.
+func (p *workDoneProgress) message() string {
+	return strings.Join(p.running, ", ")
+}
+
+func (h *LspHandler) HandleWorkDoneProgressCancel(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params types.WorkDoneProgressCancelParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	if !h.progress.cancel(params.Token) {
+		logs.Log.Logf(logs.Debug, "cancelled progress not running: %v", params.Token)
+	}
+	return nil, nil
+}
@@ -0,0 +1,107 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/konradmalik/flint-ls/core"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+func TestFormattingProgress(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+
+	tests := []struct {
+		name     string
+		command  string
+		cancel   bool
+		expected []string
+	}{
+		{"fast tool", "cat -", false, []string{}},
+		{"slow tool", "sleep 1; cat -", false, []string{"create", "begin Formatting sleep: a.txt", "end"}},
+		{"cancelled", "sleep 5; cat -", true, []string{"create", "begin Formatting sleep: a.txt", "end"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			languages := map[string][]types.Language{"text": {{FormatCommand: tt.command}}}
+			config := core.NewConfig()
+			config.Languages = &languages
+			h := NewHandler(core.NewHandler(config))
+			h.workDoneProgress = true
+			defer h.Close()
+
+			var mu sync.Mutex
+			events := make([]string, 0)
+			begun := make(chan types.ProgressToken, 1)
+			client := connectClient(t, h, func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				switch req.Method {
+				case "window/workDoneProgress/create":
+					events = append(events, "create")
+				case "$/progress":
+					var params struct {
+						Token types.ProgressToken
+						Value struct{ Kind, Title, Message string }
+					}
+					assert.NoError(t, json.Unmarshal(*req.Params, &params))
+					v := params.Value
+					switch v.Kind {
+					case "begin":
+						events = append(events, v.Kind+" "+v.Title+" "+v.Message)
+						begun <- params.Token
+					case "report":
+						events = append(events, v.Kind+" "+v.Message)
+					default:
+						events = append(events, v.Kind)
+					}
+				}
+				return nil, nil
+			})
+
+			uri := core.ParseLocalFileToURI(filepath.Join(dir, "a.txt"))
+			assert.NoError(t, client.Notify(t.Context(), "textDocument/didOpen", types.DidOpenTextDocumentParams{
+				TextDocument: types.TextDocumentItem{URI: uri, LanguageID: "text", Text: "hello\n", Version: 1},
+			}))
+
+			if tt.cancel {
+				go func() {
+					token := <-begun
+					_ = client.Notify(t.Context(), "window/workDoneProgress/cancel", types.WorkDoneProgressCancelParams{Token: token})
+				}()
+			}
+
+			start := time.Now()
+			var edits []types.TextEdit
+			err := client.Call(t.Context(), "textDocument/formatting", types.DocumentFormattingParams{
+				TextDocument: types.TextDocumentIdentifier{URI: uri},
+			}, &edits)
+			if tt.cancel {
+				var rpcErr *jsonrpc2.Error
+				assert.ErrorAs(t, err, &rpcErr)
+				assert.Equal(t, codeRequestCancelled, rpcErr.Code)
+				assert.Less(t, time.Since(start), 3*time.Second)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// notifications may arrive after the response
+			time.Sleep(50 * time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, tt.expected, events)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package lsp
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+// capabilityRegistry keeps the capabilities registered with the client in line with the configured tools.
+type capabilityRegistry struct {
+	// serializes syncs, so that registrations are sent in order
+	mu sync.Mutex
+	// methods the client can register dynamically, set during initialization
+	dynamic    map[string]bool
+	registered map[string]types.Registration
+}
+
+func newCapabilityRegistry() *capabilityRegistry {
+	return &capabilityRegistry{
+		dynamic:    make(map[string]bool),
+		registered: make(map[string]types.Registration),
+	}
+}
+
+// dynamicMethods returns the methods the client can register dynamically.
+func dynamicMethods(caps types.ClientCapabilities) map[string]bool {
+	supported := func(c *types.DynamicRegistrationCapabilities) bool { return c != nil && c.DynamicRegistration }
+	methods := make(map[string]bool)
+	if td := caps.TextDocument; td != nil {
+		methods["textDocument/willSaveWaitUntil"] = supported(td.Synchronization)
+		methods["textDocument/formatting"] = supported(td.Formatting)
+		methods["textDocument/rangeFormatting"] = supported(td.RangeFormatting)
+		methods["textDocument/codeAction"] = supported(td.CodeAction)
+		methods["textDocument/completion"] = supported(td.Completion)
+		methods["textDocument/documentSymbol"] = supported(td.DocumentSymbol)
+	}
+	if ws := caps.Workspace; ws != nil {
+		methods["workspace/symbol"] = supported(ws.Symbol)
+		methods["workspace/executeCommand"] = supported(ws.ExecuteCommand)
+		methods["workspace/didChangeWatchedFiles"] = supported(ws.DidChangeWatchedFiles)
+	}
+	return methods
+}
+
+// withoutDynamicCapabilities removes capabilities that will be registered dynamically instead.
+func withoutDynamicCapabilities(caps types.ServerCapabilities, dynamic map[string]bool) types.ServerCapabilities {
+	if dynamic["textDocument/willSaveWaitUntil"] {
+		caps.TextDocumentSync.WillSaveWaitUntil = false
+	}
+	if dynamic["textDocument/formatting"] {
+		caps.DocumentFormattingProvider = false
+	}
+	if dynamic["textDocument/rangeFormatting"] {
+		caps.RangeFormattingProvider = false
+	}
+	if dynamic["textDocument/codeAction"] {
+		caps.CodeActionProvider = nil
+	}
+	if dynamic["textDocument/completion"] {
+		caps.CompletionProvider = nil
+	}
+	if dynamic["textDocument/documentSymbol"] {
+		caps.DocumentSymbolProvider = false
+	}
+	if dynamic["workspace/symbol"] {
+		caps.WorkspaceSymbolProvider = false
+	}
+	if dynamic["workspace/executeCommand"] {
+		caps.ExecuteCommandProvider = nil
+	}
+	return caps
+}
+
+// syncRegistrations registers and unregisters capabilities to match the configuration.
+// It does not wait for the client, which answers only after the current message is handled.
+func (h *LspHandler) syncRegistrations(conn *jsonrpc2.Conn) {
+	if !h.initialized.Load() {
+		// registration is only allowed after the initialized notification
+		return
+	}
+	go func() {
+		if err := h.capabilities.sync(context.Background(), conn, h.langHandler.Registrations); err != nil {
+			logs.Log.Logln(logs.Error, err.Error())
+		}
+	}()
+}
+
+// sync calls wanted under the lock, so that the last sync always uses the latest configuration.
+func (r *capabilityRegistry) sync(ctx context.Context, conn *jsonrpc2.Conn, wanted func() []types.Registration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := make(map[string]types.Registration)
+	for _, reg := range wanted() {
+		if r.dynamic[reg.Method] {
+			next[reg.ID] = reg
+		}
+	}
+
+	unregister := make([]types.Unregistration, 0)
+	for id, reg := range r.registered {
+		if n, ok := next[id]; !ok || !reflect.DeepEqual(n, reg) {
+			unregister = append(unregister, types.Unregistration{ID: id, Method: reg.Method})
+		}
+	}
+	register := make([]types.Registration, 0)
+	for id, reg := range next {
+		if old, ok := r.registered[id]; !ok || !reflect.DeepEqual(old, reg) {
+			register = append(register, reg)
+		}
+	}
+
+	if len(unregister) > 0 {
+		err := conn.Call(ctx, "client/unregisterCapability", &types.UnregistrationParams{Unregisterations: unregister}, nil)
+		if err != nil {
+			return err
+		}
+		for _, u := range unregister {
+			delete(r.registered, u.ID)
+		}
+	}
+	if len(register) > 0 {
+		err := conn.Call(ctx, "client/registerCapability", &types.RegistrationParams{Registrations: register}, nil)
+		if err != nil {
+			return err
+		}
+		for _, reg := range register {
+			r.registered[reg.ID] = reg
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/konradmalik/flint-ls/core"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+func TestRegistrationsFollowConfiguration(t *testing.T) {
+	h := NewHandler(core.NewHandler(core.NewConfig()))
+	defer h.Close()
+
+	calls := make(chan string, 10)
+	client := connectClient(t, h, func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		var params struct {
+			Registrations    []types.Registration
+			Unregisterations []types.Unregistration
+		}
+		assert.NoError(t, json.Unmarshal(*req.Params, &params))
+		switch req.Method {
+		case "client/registerCapability":
+			for _, r := range params.Registrations {
+				calls <- "register " + r.Method
+			}
+		case "client/unregisterCapability":
+			for _, u := range params.Unregisterations {
+				calls <- "unregister " + u.Method
+			}
+		}
+		return nil, nil
+	})
+	expectCalls := func(expected ...string) {
+		t.Helper()
+		got := make([]string, 0)
+		for range expected {
+			select {
+			case c := <-calls:
+				got = append(got, c)
+			case <-time.After(time.Second):
+				t.Fatalf("expected %v, got %v", expected, got)
+			}
+		}
+		assert.ElementsMatch(t, expected, got)
+		// wait for the sync to receive the response, the connection must not close while it waits
+		h.capabilities.mu.Lock()
+		h.capabilities.mu.Unlock()
+	}
+
+	var result types.InitializeResult
+	assert.NoError(t, client.Call(t.Context(), "initialize", types.InitializeParams{
+		InitializationOptions: &types.InitializeOptions{DocumentFormatting: true, CodeAction: true},
+		Capabilities: types.ClientCapabilities{
+			TextDocument: &types.TextDocumentClientCapabilities{
+				Formatting: &types.DynamicRegistrationCapabilities{DynamicRegistration: true},
+			},
+		},
+	}, &result))
+	// registered dynamically instead
+	assert.False(t, result.Capabilities.DocumentFormattingProvider)
+	// the client cannot register it
+	assert.NotNil(t, result.Capabilities.CodeActionProvider)
+
+	languages := map[string][]types.Language{"go": {{FormatCommand: "gofmt", FixCommand: "gofix"}}}
+	assert.NoError(t, client.Notify(t.Context(), "workspace/didChangeConfiguration", types.DidChangeConfigurationParams{
+		Settings: types.Config{Languages: &languages},
+	}))
+	assert.NoError(t, client.Notify(t.Context(), "initialized", struct{}{}))
+	expectCalls("register textDocument/formatting")
+
+	languages = map[string][]types.Language{"go": {{FormatCommand: "gofmt"}}, "python": {{FormatCommand: "black -"}}}
+	assert.NoError(t, client.Notify(t.Context(), "workspace/didChangeConfiguration", types.DidChangeConfigurationParams{
+		Settings: types.Config{Languages: &languages},
+	}))
+	expectCalls("unregister textDocument/formatting", "register textDocument/formatting")
+
+	// unchanged registrations are not sent again
+	assert.NoError(t, client.Notify(t.Context(), "workspace/didChangeConfiguration", types.DidChangeConfigurationParams{
+		Settings: types.Config{Languages: &languages},
+	}))
+	languages = map[string][]types.Language{"go": {{LintCommand: "go vet"}}}
+	assert.NoError(t, client.Notify(t.Context(), "workspace/didChangeConfiguration", types.DidChangeConfigurationParams{
+		Settings: types.Config{Languages: &languages},
+	}))
+	expectCalls("unregister textDocument/formatting")
+}
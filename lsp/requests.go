@@ -0,0 +1,126 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/konradmalik/flint-ls/logs"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+const codeRequestCancelled int64 = -32800
+
+// cancellableMethods are requests that run external tools. They are handled concurrently,
+// so that $/cancelRequest can be read while they run, all other messages are handled in order.
+var cancellableMethods = map[string]bool{
+	"textDocument/formatting":        true,
+	"textDocument/rangeFormatting":   true,
+	"textDocument/willSaveWaitUntil": true,
+	"textDocument/codeAction":        true,
+	"textDocument/hover":             true,
+	"textDocument/completion":        true,
+	"textDocument/documentSymbol":    true,
+	"textDocument/diagnostic":        true,
+	"workspace/diagnostic":           true,
+	"workspace/symbol":               true,
+	"workspace/executeCommand":       true,
+}
+
+// requestRegistry maps in-flight requests to the cancel functions of their contexts.
+type requestRegistry struct {
+	mu       sync.Mutex
+	inFlight map[jsonrpc2.ID]context.CancelFunc
+}
+
+func newRequestRegistry() *requestRegistry {
+	return &requestRegistry{inFlight: make(map[jsonrpc2.ID]context.CancelFunc)}
+}
+
+func (r *requestRegistry) add(id jsonrpc2.ID, cancel context.CancelFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight[id] = cancel
+}
+
+func (r *requestRegistry) remove(id jsonrpc2.ID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.inFlight, id)
+}
+
+// cancel cancels the request, it returns false if the request is not in flight.
+func (r *requestRegistry) cancel(id jsonrpc2.ID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cancel, ok := r.inFlight[id]
+	if ok {
+		cancel()
+		delete(r.inFlight, id)
+	}
+	return ok
+}
+
+// cancelAll cancels all requests in flight.
+func (r *requestRegistry) cancelAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, cancel := range r.inFlight {
+		cancel()
+		delete(r.inFlight, id)
+	}
+}
+
+// RPCHandler returns the jsonrpc2 handler of the server.
+func (h *LspHandler) RPCHandler() jsonrpc2.Handler {
+	return rpcHandler{h}
+}
+
+type rpcHandler struct {
+	h *LspHandler
+}
+
+func (r rpcHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	if req.Notif || !cancellableMethods[req.Method] {
+		jsonrpc2.HandlerWithError(r.h.Handle).Handle(ctx, conn, req)
+		return
+	}
+
+	// registered before the next message is read, so that a following $/cancelRequest finds it
+	reqCtx, cancel := context.WithCancel(ctx)
+	r.h.requests.add(req.ID, cancel)
+
+	go func() {
+		defer cancel()
+		defer r.h.requests.remove(req.ID)
+
+		jsonrpc2.HandlerWithError(func(_ context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+			result, err := r.h.Handle(reqCtx, conn, req)
+			// also cancelled when the user cancels the progress of the request
+			if reqCtx.Err() != nil || errors.Is(err, context.Canceled) {
+				logs.Log.Logf(logs.Debug, "request cancelled: %v %v", req.Method, req.ID)
+				return nil, &jsonrpc2.Error{Code: codeRequestCancelled, Message: "request cancelled"}
+			}
+			return result, err
+		}).Handle(ctx, conn, req)
+	}()
+}
+
+func (h *LspHandler) HandleCancelRequest(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params types.CancelParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	if !h.requests.cancel(params.ID) {
+		logs.Log.Logf(logs.Debug, "cancelled request not in flight: %v", params.ID)
+	}
+	return nil, nil
+}
@@ -0,0 +1,81 @@
+package lsp
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/konradmalik/flint-ls/core"
+	"github.com/konradmalik/flint-ls/types"
+)
+
+func TestCancelRequestKillsTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "finished")
+
+	languages := map[string][]types.Language{
+		"text": {{FormatCommand: "(sleep 1; touch " + marker + "); cat -"}},
+	}
+	config := core.NewConfig()
+	config.Languages = &languages
+	h := NewHandler(core.NewHandler(config))
+	defer h.Close()
+
+	client := connectClient(t, h, func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (any, error) { return nil, nil })
+
+	uri := core.ParseLocalFileToURI(filepath.Join(dir, "a.txt"))
+	err := client.Notify(t.Context(), "textDocument/didOpen", types.DidOpenTextDocumentParams{
+		TextDocument: types.TextDocumentItem{URI: uri, LanguageID: "text", Text: "hello\n", Version: 1},
+	})
+	assert.NoError(t, err)
+
+	id := jsonrpc2.ID{Num: 42}
+	done := make(chan error)
+	start := time.Now()
+	go func() {
+		var edits []types.TextEdit
+		done <- client.Call(t.Context(), "textDocument/formatting", types.DocumentFormattingParams{
+			TextDocument: types.TextDocumentIdentifier{URI: uri},
+		}, &edits, jsonrpc2.PickID(id))
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, client.Notify(t.Context(), "$/cancelRequest", types.CancelParams{ID: id}))
+
+	select {
+	case err := <-done:
+		var rpcErr *jsonrpc2.Error
+		assert.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, codeRequestCancelled, rpcErr.Code)
+	case <-time.After(3 * time.Second):
+		t.Fatal("request was not cancelled")
+	}
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the formatter would have finished by now if it was still running
+	time.Sleep(1500 * time.Millisecond)
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err), "formatter kept running after cancel")
+}
+
+// connectClient connects a client handled by handle to the server.
+func connectClient(t *testing.T, h *LspHandler, handle func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (any, error)) *jsonrpc2.Conn {
+	serverSide, clientSide := net.Pipe()
+	server := jsonrpc2.NewConn(t.Context(), jsonrpc2.NewBufferedStream(serverSide, jsonrpc2.VSCodeObjectCodec{}), h.RPCHandler())
+	client := jsonrpc2.NewConn(t.Context(), jsonrpc2.NewBufferedStream(clientSide, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(handle))
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	return client
+}
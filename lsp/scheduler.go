@@ -0,0 +1,181 @@
+package lsp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/konradmalik/flint-ls/types"
+)
+
+type lintFunc func(ctx context.Context, eventType types.EventType)
+
+// lintScheduler debounces lint requests per document and makes sure
+// that only one lint run per document is in flight.
+type lintScheduler struct {
+	mu       sync.Mutex
+	debounce time.Duration
+	pending  map[types.DocumentURI]*pendingLint
+	running  map[types.DocumentURI]*runningLint
+}
+
+type pendingLint struct {
+	timer     *time.Timer
+	eventType types.EventType
+	run       lintFunc
+}
+
+type runningLint struct {
+	cancel context.CancelFunc
+}
+
+func newLintScheduler() *lintScheduler {
+	return &lintScheduler{
+		pending: make(map[types.DocumentURI]*pendingLint),
+		running: make(map[types.DocumentURI]*runningLint),
+	}
+}
+
+func (s *lintScheduler) SetDebounce(debounce time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.debounce = debounce
+}
+
+// Schedule queues a lint run for the document after the debounce period.
+// Events for a document that arrive before the period elapses are coalesced,
+// keeping the strongest event type and the most recent run function.
+func (s *lintScheduler) Schedule(uri types.DocumentURI, eventType types.EventType, run lintFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.pending[uri]; ok {
+		p.eventType = strongerEvent(p.eventType, eventType)
+		p.run = run
+		p.timer.Reset(s.debounce)
+		return
+	}
+
+	p := &pendingLint{eventType: eventType, run: run}
+	p.timer = time.AfterFunc(s.debounce, func() { s.fire(uri) })
+	s.pending[uri] = p
+}
+
+func (s *lintScheduler) fire(uri types.DocumentURI) {
+	s.mu.Lock()
+	p, ok := s.pending[uri]
+	if !ok {
+		// timer was reset after it had already fired, the run was already started
+		s.mu.Unlock()
+		return
+	}
+	delete(s.pending, uri)
+
+	if r, ok := s.running[uri]; ok {
+		r.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &runningLint{cancel: cancel}
+	s.running[uri] = r
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if s.running[uri] == r {
+			delete(s.running, uri)
+		}
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	p.run(ctx, p.eventType)
+}
+
+// Cancel cancels the pending and running lint of the document.
+func (s *lintScheduler) Cancel(uri types.DocumentURI) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel(uri)
+}
+
+// Stop cancels all pending and running lints.
+func (s *lintScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uri := range s.pending {
+		s.cancel(uri)
+	}
+	for uri := range s.running {
+		s.cancel(uri)
+	}
+}
+
+// cancel expects the caller to hold mu.
+func (s *lintScheduler) cancel(uri types.DocumentURI) {
+	if p, ok := s.pending[uri]; ok {
+		p.timer.Stop()
+		delete(s.pending, uri)
+	}
+	if r, ok := s.running[uri]; ok {
+		r.cancel()
+		delete(s.running, uri)
+	}
+}
+
+// pendingEvents remembers the strongest event per document until diagnostics are pulled by the client.
+type pendingEvents struct {
+	mu     sync.Mutex
+	events map[types.DocumentURI]types.EventType
+}
+
+func newPendingEvents() *pendingEvents {
+	return &pendingEvents{events: make(map[types.DocumentURI]types.EventType)}
+}
+
+func (p *pendingEvents) record(uri types.DocumentURI, eventType types.EventType) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.events[uri]; ok {
+		eventType = strongerEvent(e, eventType)
+	}
+	p.events[uri] = eventType
+}
+
+// take returns and forgets the pending event, change is returned if there is none.
+func (p *pendingEvents) take(uri types.DocumentURI) types.EventType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	eventType, ok := p.events[uri]
+	if !ok {
+		return types.EventTypeChange
+	}
+	delete(p.events, uri)
+	return eventType
+}
+
+func (p *pendingEvents) forget(uri types.DocumentURI) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.events, uri)
+}
+
+// strongerEvent returns the event that enables the most relevant lint run,
+// save is stronger than open which is stronger than change.
+func strongerEvent(a, b types.EventType) types.EventType {
+	if eventStrength(b) > eventStrength(a) {
+		return b
+	}
+	return a
+}
+
+func eventStrength(e types.EventType) int {
+	switch e {
+	case types.EventTypeSave:
+		return 2
+	case types.EventTypeOpen:
+		return 1
+	default:
+		return 0
+	}
+}
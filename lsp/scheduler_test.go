@@ -0,0 +1,105 @@
+package lsp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/konradmalik/flint-ls/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintSchedulerCoalescesPerDocument(t *testing.T) {
+	s := newLintScheduler()
+	s.SetDebounce(20 * time.Millisecond)
+	defer s.Stop()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	runs := make(map[types.DocumentURI][]types.EventType)
+	record := func(uri types.DocumentURI) lintFunc {
+		return func(_ context.Context, eventType types.EventType) {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[uri] = append(runs[uri], eventType)
+			wg.Done()
+		}
+	}
+
+	wg.Add(2)
+	s.Schedule("file:///a", types.EventTypeChange, record("file:///a"))
+	s.Schedule("file:///a", types.EventTypeSave, record("file:///a"))
+	s.Schedule("file:///b", types.EventTypeChange, record("file:///b"))
+	s.Schedule("file:///a", types.EventTypeChange, record("file:///a"))
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []types.EventType{types.EventTypeSave}, runs["file:///a"])
+	assert.Equal(t, []types.EventType{types.EventTypeChange}, runs["file:///b"])
+}
+
+func TestLintSchedulerCancelsOnlySameDocument(t *testing.T) {
+	s := newLintScheduler()
+	defer s.Stop()
+
+	started := make(chan struct{}, 2)
+	cancelled := make(chan types.DocumentURI, 2)
+	blocking := func(uri types.DocumentURI) lintFunc {
+		return func(ctx context.Context, _ types.EventType) {
+			started <- struct{}{}
+			<-ctx.Done()
+			cancelled <- uri
+		}
+	}
+
+	s.Schedule("file:///a", types.EventTypeChange, blocking("file:///a"))
+	s.Schedule("file:///b", types.EventTypeChange, blocking("file:///b"))
+	<-started
+	<-started
+
+	done := make(chan struct{})
+	s.Schedule("file:///a", types.EventTypeChange, func(context.Context, types.EventType) { close(done) })
+	<-done
+
+	select {
+	case uri := <-cancelled:
+		assert.Equal(t, types.DocumentURI("file:///a"), uri)
+	case <-time.After(time.Second):
+		t.Fatal("previous run was not cancelled")
+	}
+	select {
+	case uri := <-cancelled:
+		t.Fatalf("unexpected cancel of %s", uri)
+	default:
+	}
+}
+
+func TestLintSchedulerCancelDocument(t *testing.T) {
+	s := newLintScheduler()
+	defer s.Stop()
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	s.Schedule("file:///a", types.EventTypeChange, func(ctx context.Context, _ types.EventType) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+	})
+	<-started
+	s.Cancel("file:///a")
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("running lint was not cancelled")
+	}
+
+	s.SetDebounce(20 * time.Millisecond)
+	s.Schedule("file:///a", types.EventTypeChange, func(context.Context, types.EventType) {
+		t.Error("pending lint was not cancelled")
+	})
+	s.Cancel("file:///a")
+	time.Sleep(50 * time.Millisecond)
+}
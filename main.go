@@ -23,10 +23,12 @@ var revision = "HEAD"
 
 func main() {
 	var logfile string
+	var configPath string
 	var loglevel int
 	var showVersion bool
 	var usage bool
 
+	flag.StringVar(&configPath, "config", "", "YAML or JSON configuration file. Settings from the client are layered on top.")
 	flag.StringVar(&logfile, "logfile", "", "File to save logs into. If provided stderr won't be used anymore.")
 	flag.IntVar(&loglevel, "loglevel", 2, "Set the log level. Max is 3 (debug), min is 0 (error). Higher number logs less. Set <0 for no logs.")
 	flag.BoolVar(&showVersion, "v", false, "Print the version")
@@ -44,6 +46,14 @@ func main() {
 	}
 
 	config := core.NewConfig()
+	if configPath != "" {
+		var err error
+		config, err = core.LoadConfigFile(configPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 	logs.InitializeLogger(logfile, logs.LogLevel(max(loglevel, -1)))
 	logs.Log.Logln(logs.Info, "reading on stdin, writing on stdout")
 
@@ -56,10 +66,11 @@ func main() {
 
 	internalHandler := core.NewHandler(config)
 	handler := lsp.NewHandler(internalHandler)
+	handler.UpdateConfiguration(config)
 	<-jsonrpc2.NewConn(
 		context.Background(),
 		jsonrpc2.NewBufferedStream(stdrwc{}, jsonrpc2.VSCodeObjectCodec{}),
-		jsonrpc2.HandlerWithError(handler.Handle),
+		handler.RPCHandler(),
 		jsonrpc2.LogMessages(logs.Log)).DisconnectNotify()
 
 	logs.Log.Logln(logs.Info, "flint-ls: connections closed")
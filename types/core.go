@@ -9,22 +9,61 @@ type Config struct {
 	RootMarkers    *[]string              `json:"rootMarkers,omitempty"`
 	LintDebounce   time.Duration          `json:"lintDebounce,omitempty"`
 	FormatDebounce time.Duration          `json:"formatDebounce,omitempty"`
+	// time budget of formatting in willSaveWaitUntil, the document is saved unformatted when exceeded
+	FormatOnSaveTimeout time.Duration `json:"formatOnSaveTimeout,omitempty"`
+	Commands            *[]Command    `json:"commands,omitempty"`
+	// tool definitions that languages refer to by name
+	Tools *map[string]Language `json:"tools,omitempty"`
+	// directories whose project configuration files are used, including projects below them.
+	// Project files configure commands that run when a document is opened, so others are ignored
+	TrustedProjects *[]string `json:"trustedProjects,omitempty"`
 }
 
+// Command is run by workspace/executeCommand. The first argument of the request, if any, is the document URI
+// used for the placeholders and passed on stdin.
+type Command struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Command string `json:"command"`
+	// what to do with the output of the command, ignored by default
+	Output CommandOutput `json:"output,omitempty"`
+	Env    []string      `json:"env,omitempty"`
+}
+
+type CommandOutput string
+
+const (
+	CommandOutputIgnore CommandOutput = "ignore"
+	// output replaces the contents of the document
+	CommandOutputApply CommandOutput = "apply"
+	// output is shown to the user
+	CommandOutputMessage CommandOutput = "message"
+)
+
 type Language struct {
+	// name of a tool definition in Config.Tools, fields set here override those of the definition
+	Tool string `json:"tool,omitempty"`
+	// name of a built-in preset, fields set here or in the tool definition override those of the preset
+	Preset        string   `json:"preset,omitempty"`
 	Env           []string `json:"env,omitempty"`
 	RootMarkers   []string `json:"rootMarkers,omitempty"`
-	RequireMarker bool     `json:"requireMarker,omitempty"`
+	RequireMarker *bool    `json:"requireMarker,omitempty"`
 	// prefix for lint message
 	Prefix      string   `json:"prefix,omitempty"`
 	LintFormats []string `json:"lintFormats,omitempty"`
-	LintStdin   bool     `json:"lintStdin,omitempty"`
+	LintStdin   *bool    `json:"lintStdin,omitempty"`
 	// warning: this will be subtracted from the line reported by the linter
-	LintOffset int `json:"lintOffset,omitempty"`
+	LintOffset *int `json:"lintOffset,omitempty"`
 	// warning: this will be added to the column reported by the linter
-	LintOffsetColumns  int                `json:"lintOffsetColumns,omitempty"`
-	LintCommand        string             `json:"lintCommand,omitempty"`
-	LintIgnoreExitCode bool               `json:"lintIgnoreExitCode,omitempty"`
+	LintOffsetColumns *int `json:"lintOffsetColumns,omitempty"`
+	// unit of columns reported by the linter, defaults to utf16
+	LintColumnUnit ColumnUnit `json:"lintColumnUnit,omitempty"`
+	// width of a tab when LintColumnUnit is display, defaults to 8
+	LintTabWidth int    `json:"lintTabWidth,omitempty"`
+	LintCommand  string `json:"lintCommand,omitempty"`
+	// lint the whole root at once instead of a single file, LintCommand gets no input file
+	LintWorkspace      *bool              `json:"lintWorkspace,omitempty"`
+	LintIgnoreExitCode *bool              `json:"lintIgnoreExitCode,omitempty"`
 	LintCategoryMap    map[string]string  `json:"lintCategoryMap,omitempty"`
 	LintSource         string             `json:"lintSource,omitempty"`
 	LintSeverity       DiagnosticSeverity `json:"lintSeverity,omitempty"`
@@ -33,11 +72,43 @@ type Language struct {
 	// defaults to true if not provided as a sanity default
 	LintOnChange *bool `json:"lintOnChange,omitempty"`
 	// defaults to true if not provided as a sanity default
-	LintOnSave     *bool  `json:"lintOnSave,omitempty"`
-	FormatCommand  string `json:"formatCommand,omitempty"`
-	FormatCanRange bool   `json:"formatCanRange,omitempty"`
+	LintOnSave *bool `json:"lintOnSave,omitempty"`
+	// globs of files, relative to the root, whose changes re-lint open documents, e.g. .eslintrc*.
+	// Globs without a slash match the file name anywhere under the root
+	LintWatchFiles []string `json:"lintWatchFiles,omitempty"`
+	FormatCommand  string   `json:"formatCommand,omitempty"`
+	FormatCanRange *bool    `json:"formatCanRange,omitempty"`
+	// format in willSaveWaitUntil, for clients that only format on save that way
+	FormatOnSave *bool `json:"formatOnSave,omitempty"`
+	// reads the document on stdin and prints it with fixes applied, offered as code actions
+	FixCommand string `json:"fixCommand,omitempty"`
+	// explains a diagnostic code in hover, ${CODE} is replaced with the code of the diagnostic
+	ExplainCommand string `json:"explainCommand,omitempty"`
+	// reads the document on stdin and prints completion candidates, ${ROW} and ${COL} are the 1-based cursor position
+	CompletionCommand string `json:"completionCommand,omitempty"`
+	// regular expression matching a candidate in a line of CompletionCommand output, with named groups label and detail
+	CompletionPattern string `json:"completionPattern,omitempty"`
+	// lists symbols of a document, e.g. universal-ctags with --output-format=json
+	SymbolCommand string `json:"symbolCommand,omitempty"`
+	// lists symbols of the whole root, run without an input file
+	WorkspaceSymbolCommand string `json:"workspaceSymbolCommand,omitempty"`
+	// regular expression matching a symbol in a line of output, with named groups name, kind, line, column,
+	// file and container. Output is parsed as universal-ctags JSON when empty
+	SymbolPattern string `json:"symbolPattern,omitempty"`
+	// maps kinds reported by the tool to LSP symbol kind names, e.g. f: function
+	SymbolKindMap map[string]string `json:"symbolKindMap,omitempty"`
 }
 
+type ColumnUnit string
+
+const (
+	ColumnUnitByte  ColumnUnit = "byte"
+	ColumnUnitRune  ColumnUnit = "rune"
+	ColumnUnitUTF16 ColumnUnit = "utf16"
+	// columns as shown in a terminal, tabs expand to the next multiple of the tab width
+	ColumnUnitDisplay ColumnUnit = "display"
+)
+
 type EventType int
 
 const (
@@ -1,19 +1,84 @@
 package types
 
+import (
+	"encoding/json"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
 type DocumentURI string
 
 type InitializeParams struct {
 	RootURI               DocumentURI        `json:"rootUri,omitempty"`
 	InitializationOptions *InitializeOptions `json:"initializationOptions,omitempty"`
 	Capabilities          ClientCapabilities `json:"capabilities"`
+	WorkspaceFolders      []WorkspaceFolder  `json:"workspaceFolders,omitempty"`
+}
+
+type WorkspaceFolder struct {
+	URI  DocumentURI `json:"uri"`
+	Name string      `json:"name"`
 }
 
 type InitializeOptions struct {
 	DocumentFormatting bool `json:"documentFormatting"`
 	RangeFormatting    bool `json:"documentRangeFormatting"`
+	CodeAction         bool `json:"codeAction"`
+	Completion         bool `json:"completion"`
+	DocumentSymbol     bool `json:"documentSymbol"`
+	WorkspaceSymbol    bool `json:"workspaceSymbol"`
+}
+
+type ClientCapabilities struct {
+	General      *GeneralClientCapabilities      `json:"general,omitempty"`
+	TextDocument *TextDocumentClientCapabilities `json:"textDocument,omitempty"`
+	Window       *WindowClientCapabilities       `json:"window,omitempty"`
+	Workspace    *WorkspaceClientCapabilities    `json:"workspace,omitempty"`
+}
+
+type DynamicRegistrationCapabilities struct {
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+}
+
+type WorkspaceClientCapabilities struct {
+	// set when the client supports workspace/configuration
+	Configuration         bool                                   `json:"configuration,omitempty"`
+	DidChangeWatchedFiles *DynamicRegistrationCapabilities       `json:"didChangeWatchedFiles,omitempty"`
+	Diagnostics           *DiagnosticWorkspaceClientCapabilities `json:"diagnostics,omitempty"`
+	Symbol                *DynamicRegistrationCapabilities       `json:"symbol,omitempty"`
+	ExecuteCommand        *DynamicRegistrationCapabilities       `json:"executeCommand,omitempty"`
 }
 
-type ClientCapabilities struct{}
+type DiagnosticWorkspaceClientCapabilities struct {
+	// set when the client supports workspace/diagnostic/refresh
+	RefreshSupport bool `json:"refreshSupport,omitempty"`
+}
+
+type WindowClientCapabilities struct {
+	// set when the client supports server initiated progress
+	WorkDoneProgress bool `json:"workDoneProgress,omitempty"`
+}
+
+type TextDocumentClientCapabilities struct {
+	// set when the client supports pull diagnostics
+	Diagnostic      *DiagnosticClientCapabilities    `json:"diagnostic,omitempty"`
+	Synchronization *DynamicRegistrationCapabilities `json:"synchronization,omitempty"`
+	Formatting      *DynamicRegistrationCapabilities `json:"formatting,omitempty"`
+	RangeFormatting *DynamicRegistrationCapabilities `json:"rangeFormatting,omitempty"`
+	CodeAction      *DynamicRegistrationCapabilities `json:"codeAction,omitempty"`
+	Completion      *DynamicRegistrationCapabilities `json:"completion,omitempty"`
+	DocumentSymbol  *DynamicRegistrationCapabilities `json:"documentSymbol,omitempty"`
+}
+
+type DiagnosticClientCapabilities struct {
+	DynamicRegistration    bool `json:"dynamicRegistration,omitempty"`
+	RelatedDocumentSupport bool `json:"relatedDocumentSupport,omitempty"`
+}
+
+type GeneralClientCapabilities struct {
+	// in order of client preference
+	PositionEncodings []PositionEncodingKind `json:"positionEncodings,omitempty"`
+}
 
 type InitializeResult struct {
 	Capabilities ServerCapabilities `json:"capabilities"`
@@ -39,8 +104,9 @@ const (
 )
 
 type TextDocumentSyncOptions struct {
-	OpenClose bool                 `json:"openClose,omitempty"`
-	Change    TextDocumentSyncKind `json:"change,omitempty"`
+	OpenClose         bool                 `json:"openClose,omitempty"`
+	Change            TextDocumentSyncKind `json:"change,omitempty"`
+	WillSaveWaitUntil bool                 `json:"willSaveWaitUntil,omitempty"`
 }
 
 type PositionEncodingKind string
@@ -52,10 +118,45 @@ const (
 )
 
 type ServerCapabilities struct {
-	PositionEncoding           PositionEncodingKind    `json:"positionEncoding,omitempty"`
-	TextDocumentSync           TextDocumentSyncOptions `json:"textDocumentSync,omitempty"`
-	DocumentFormattingProvider bool                    `json:"documentFormattingProvider,omitempty"`
-	RangeFormattingProvider    bool                    `json:"documentRangeFormattingProvider,omitempty"`
+	PositionEncoding           PositionEncodingKind         `json:"positionEncoding,omitempty"`
+	TextDocumentSync           TextDocumentSyncOptions      `json:"textDocumentSync,omitempty"`
+	DocumentFormattingProvider bool                         `json:"documentFormattingProvider,omitempty"`
+	RangeFormattingProvider    bool                         `json:"documentRangeFormattingProvider,omitempty"`
+	DiagnosticProvider         *DiagnosticOptions           `json:"diagnosticProvider,omitempty"`
+	CodeActionProvider         *CodeActionOptions           `json:"codeActionProvider,omitempty"`
+	HoverProvider              bool                         `json:"hoverProvider,omitempty"`
+	CompletionProvider         *CompletionOptions           `json:"completionProvider,omitempty"`
+	DocumentSymbolProvider     bool                         `json:"documentSymbolProvider,omitempty"`
+	WorkspaceSymbolProvider    bool                         `json:"workspaceSymbolProvider,omitempty"`
+	ExecuteCommandProvider     *ExecuteCommandOptions       `json:"executeCommandProvider,omitempty"`
+	Workspace                  *WorkspaceServerCapabilities `json:"workspace,omitempty"`
+}
+
+type WorkspaceServerCapabilities struct {
+	WorkspaceFolders *WorkspaceFoldersServerCapabilities `json:"workspaceFolders,omitempty"`
+}
+
+type WorkspaceFoldersServerCapabilities struct {
+	Supported           bool `json:"supported"`
+	ChangeNotifications bool `json:"changeNotifications"`
+}
+
+type ExecuteCommandOptions struct {
+	Commands []string `json:"commands"`
+}
+
+type CompletionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+	ResolveProvider   bool     `json:"resolveProvider,omitempty"`
+}
+
+type CodeActionOptions struct {
+	CodeActionKinds []CodeActionKind `json:"codeActionKinds,omitempty"`
+}
+
+type DiagnosticOptions struct {
+	InterFileDependencies bool `json:"interFileDependencies"`
+	WorkspaceDiagnostics  bool `json:"workspaceDiagnostics"`
 }
 
 type TextDocumentItem struct {
@@ -82,9 +183,10 @@ type DidCloseTextDocumentParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 }
 
+// Range is nil when Text is the full content of the document
 type TextDocumentContentChangeEvent struct {
-	Range       Range  `json:"range"`
-	RangeLength int    `json:"rangeLength"`
+	Range       *Range `json:"range,omitempty"`
+	RangeLength int    `json:"rangeLength,omitempty"`
 	Text        string `json:"text"`
 }
 
@@ -93,6 +195,20 @@ type DidChangeTextDocumentParams struct {
 	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
 }
 
+type TextDocumentSaveReason int
+
+const (
+	_ TextDocumentSaveReason = iota
+	SaveManual
+	SaveAfterDelay
+	SaveFocusOut
+)
+
+type WillSaveTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Reason       TextDocumentSaveReason `json:"reason"`
+}
+
 type DidSaveTextDocumentParams struct {
 	Text         *string                `json:"text"`
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
@@ -135,16 +251,96 @@ const (
 type Diagnostic struct {
 	Range              Range                          `json:"range"`
 	Severity           DiagnosticSeverity             `json:"severity,omitempty"`
-	Code               *int                           `json:"code,omitempty"`
+	Code               DiagnosticCode                 `json:"code,omitempty"`
 	Source             *string                        `json:"source,omitempty"`
 	Message            string                         `json:"message"`
 	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
 }
 
+// DiagnosticCode is the code of a diagnostic as reported by the tool, like 2086 or SC2086.
+// Codes of digits only are sent as numbers.
+type DiagnosticCode string
+
+func (c DiagnosticCode) MarshalJSON() ([]byte, error) {
+	if isNumber(string(c)) {
+		return []byte(c), nil
+	}
+	return json.Marshal(string(c))
+}
+
+func (c *DiagnosticCode) UnmarshalJSON(b []byte) error {
+	var code string
+	if err := json.Unmarshal(b, &code); err == nil {
+		*c = DiagnosticCode(code)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*c = DiagnosticCode(n)
+	return nil
+}
+
+// isNumber reports whether s is a valid json integer without a sign.
+func isNumber(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 type PublishDiagnosticsParams struct {
 	URI         DocumentURI  `json:"uri"`
 	Diagnostics []Diagnostic `json:"diagnostics"`
-	Version     int          `json:"version"`
+	// omitted for documents that are not open
+	Version int `json:"version,omitempty"`
+}
+
+type DocumentDiagnosticParams struct {
+	TextDocument     TextDocumentIdentifier `json:"textDocument"`
+	Identifier       string                 `json:"identifier,omitempty"`
+	PreviousResultID string                 `json:"previousResultId,omitempty"`
+}
+
+type DocumentDiagnosticReportKind string
+
+const (
+	DiagnosticReportFull      DocumentDiagnosticReportKind = "full"
+	DiagnosticReportUnchanged DocumentDiagnosticReportKind = "unchanged"
+)
+
+type DocumentDiagnosticReport struct {
+	Kind     DocumentDiagnosticReportKind `json:"kind"`
+	ResultID string                       `json:"resultId,omitempty"`
+	// nil for unchanged reports, full reports always send the list, even if empty
+	Items *[]Diagnostic `json:"items,omitempty"`
+}
+
+type PreviousResultID struct {
+	URI   DocumentURI `json:"uri"`
+	Value string      `json:"value"`
+}
+
+type WorkspaceDiagnosticParams struct {
+	Identifier        string             `json:"identifier,omitempty"`
+	PreviousResultIDs []PreviousResultID `json:"previousResultIds"`
+}
+
+type WorkspaceDocumentDiagnosticReport struct {
+	DocumentDiagnosticReport
+	URI DocumentURI `json:"uri"`
+	// nil for documents that are not open
+	Version *int `json:"version"`
+}
+
+type WorkspaceDiagnosticReport struct {
+	Items []WorkspaceDocumentDiagnosticReport `json:"items"`
 }
 
 // https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#formattingOptions
@@ -166,6 +362,44 @@ type TextEdit struct {
 	NewText string `json:"newText"`
 }
 
+type CodeActionKind string
+
+const (
+	CodeActionQuickFix CodeActionKind = "quickfix"
+	CodeActionSource   CodeActionKind = "source"
+	// tool specific kinds are appended, e.g. source.fixAll.ruff
+	CodeActionSourceFixAll CodeActionKind = "source.fixAll"
+)
+
+type CodeActionContext struct {
+	Diagnostics []Diagnostic     `json:"diagnostics"`
+	Only        []CodeActionKind `json:"only,omitempty"`
+}
+
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+
+type WorkspaceEdit struct {
+	Changes         map[DocumentURI][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []TextDocumentEdit         `json:"documentChanges,omitempty"`
+}
+
+type TextDocumentEdit struct {
+	TextDocument VersionedTextDocumentIdentifier `json:"textDocument"`
+	Edits        []TextEdit                      `json:"edits"`
+}
+
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        CodeActionKind `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+}
+
 type DidChangeConfigurationParams struct {
 	Settings Config `json:"settings"`
 }
@@ -174,3 +408,227 @@ type LogMessageParams struct {
 	Type    MessageType `json:"type"`
 	Message string      `json:"message"`
 }
+
+type HoverParams struct {
+	TextDocumentPositionParams
+}
+
+type MarkupKind string
+
+const (
+	PlainText MarkupKind = "plaintext"
+	Markdown  MarkupKind = "markdown"
+)
+
+type MarkupContent struct {
+	Kind  MarkupKind `json:"kind"`
+	Value string     `json:"value"`
+}
+
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+type CompletionParams struct {
+	TextDocumentPositionParams
+}
+
+type CompletionItemKind int
+
+const (
+	CompletionItemKindText CompletionItemKind = 1
+)
+
+type CompletionItem struct {
+	Label  string             `json:"label"`
+	Kind   CompletionItemKind `json:"kind,omitempty"`
+	Detail string             `json:"detail,omitempty"`
+}
+
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+type SymbolKind int
+
+const (
+	SymbolKindFile SymbolKind = iota + 1
+	SymbolKindModule
+	SymbolKindNamespace
+	SymbolKindPackage
+	SymbolKindClass
+	SymbolKindMethod
+	SymbolKindProperty
+	SymbolKindField
+	SymbolKindConstructor
+	SymbolKindEnum
+	SymbolKindInterface
+	SymbolKindFunction
+	SymbolKindVariable
+	SymbolKindConstant
+	SymbolKindString
+	SymbolKindNumber
+	SymbolKindBoolean
+	SymbolKindArray
+	SymbolKindObject
+	SymbolKindKey
+	SymbolKindNull
+	SymbolKindEnumMember
+	SymbolKindStruct
+	SymbolKindEvent
+	SymbolKindOperator
+	SymbolKindTypeParameter
+)
+
+type SymbolInformation struct {
+	Name          string     `json:"name"`
+	Kind          SymbolKind `json:"kind"`
+	Location      Location   `json:"location"`
+	ContainerName string     `json:"containerName,omitempty"`
+}
+
+type ExecuteCommandParams struct {
+	Command   string `json:"command"`
+	Arguments []any  `json:"arguments,omitempty"`
+}
+
+type ApplyWorkspaceEditParams struct {
+	Label string        `json:"label,omitempty"`
+	Edit  WorkspaceEdit `json:"edit"`
+}
+
+type ApplyWorkspaceEditResult struct {
+	Applied       bool   `json:"applied"`
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+type ShowMessageParams struct {
+	Type    MessageType `json:"type"`
+	Message string      `json:"message"`
+}
+
+type CancelParams struct {
+	ID jsonrpc2.ID `json:"id"`
+}
+
+// ProgressToken is an integer or a string, like request ids.
+type ProgressToken = jsonrpc2.ID
+
+type WorkDoneProgressCreateParams struct {
+	Token ProgressToken `json:"token"`
+}
+
+type WorkDoneProgressCancelParams struct {
+	Token ProgressToken `json:"token"`
+}
+
+type ProgressParams struct {
+	Token ProgressToken `json:"token"`
+	Value any           `json:"value"`
+}
+
+type WorkDoneProgressBegin struct {
+	Kind        string `json:"kind"`
+	Title       string `json:"title"`
+	Cancellable bool   `json:"cancellable,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+type WorkDoneProgressReport struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message,omitempty"`
+}
+
+type WorkDoneProgressEnd struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message,omitempty"`
+}
+
+type DocumentFilter struct {
+	Language string `json:"language,omitempty"`
+	Scheme   string `json:"scheme,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+}
+
+type TextDocumentRegistrationOptions struct {
+	// null means the document selector of the client
+	DocumentSelector []DocumentFilter `json:"documentSelector"`
+}
+
+type CodeActionRegistrationOptions struct {
+	TextDocumentRegistrationOptions
+	CodeActionOptions
+}
+
+type CompletionRegistrationOptions struct {
+	TextDocumentRegistrationOptions
+	CompletionOptions
+}
+
+type Registration struct {
+	ID              string `json:"id"`
+	Method          string `json:"method"`
+	RegisterOptions any    `json:"registerOptions,omitempty"`
+}
+
+type RegistrationParams struct {
+	Registrations []Registration `json:"registrations"`
+}
+
+type Unregistration struct {
+	ID     string `json:"id"`
+	Method string `json:"method"`
+}
+
+type UnregistrationParams struct {
+	// misspelled in the specification
+	Unregisterations []Unregistration `json:"unregisterations"`
+}
+
+type ConfigurationItem struct {
+	ScopeURI DocumentURI `json:"scopeUri,omitempty"`
+	Section  string      `json:"section,omitempty"`
+}
+
+type ConfigurationParams struct {
+	Items []ConfigurationItem `json:"items"`
+}
+
+type WorkspaceFoldersChangeEvent struct {
+	Added   []WorkspaceFolder `json:"added"`
+	Removed []WorkspaceFolder `json:"removed"`
+}
+
+type DidChangeWorkspaceFoldersParams struct {
+	Event WorkspaceFoldersChangeEvent `json:"event"`
+}
+
+type FileSystemWatcher struct {
+	GlobPattern string `json:"globPattern"`
+}
+
+type DidChangeWatchedFilesRegistrationOptions struct {
+	Watchers []FileSystemWatcher `json:"watchers"`
+}
+
+type FileChangeType int
+
+const (
+	FileCreated FileChangeType = iota + 1
+	FileChanged
+	FileDeleted
+)
+
+type FileEvent struct {
+	URI  DocumentURI    `json:"uri"`
+	Type FileChangeType `json:"type"`
+}
+
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
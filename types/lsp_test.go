@@ -0,0 +1,31 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticCodeJSON(t *testing.T) {
+	tests := []struct {
+		code DiagnosticCode
+		json string
+	}{
+		{"2086", `{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}},"code":2086,"message":""}`},
+		{"SC2086", `{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}},"code":"SC2086","message":""}`},
+		{"0612", `{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}},"code":"0612","message":""}`},
+		{"", `{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}},"message":""}`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			b, err := json.Marshal(Diagnostic{Code: tt.code})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.json, string(b))
+
+			var d Diagnostic
+			assert.NoError(t, json.Unmarshal(b, &d))
+			assert.Equal(t, tt.code, d.Code)
+		})
+	}
+}